@@ -16,16 +16,24 @@ package cmd
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/dkoshkin/gofer/pkg/dependency"
 	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
 	"github.com/spf13/cobra"
 )
 
 var mask string
+var constraint string
 var sourceType string
-
-var validTypes = []string{"github", "docker", "manual"}
+var source string
+var prerelease string
+var updatePolicy string
+var depMinAge string
 
 // addCmd represents the add command
 var addCmd = &cobra.Command{
@@ -40,18 +48,26 @@ var addCmd = &cobra.Command{
 			return err
 		}
 		dep := dependency.Spec{
-			Type:    sourceType,
-			Name:    args[0],
-			Version: args[1],
-			Mask:    mask,
+			Type:         sourceType,
+			Name:         args[0],
+			Version:      args[1],
+			Mask:         mask,
+			Constraint:   constraint,
+			Source:       source,
+			Prerelease:   prerelease,
+			UpdatePolicy: updatePolicy,
+			MinAge:       depMinAge,
 		}
 		if sourceType != "" {
-			if !stringInSlice(sourceType, validTypes) {
+			if !stringInSlice(sourceType, dependency.ValidTypes(fetchers)) {
 				return fmt.Errorf("dependency not added, %q is not a valid type", sourceType)
 			}
 			dep.Type = sourceType
 		} else {
-			dep.Type = dependency.DetermineType(args[1])
+			dep.Type = dependency.DetermineType(fetchers, args[0])
+		}
+		if err := validateSpec(dep); err != nil {
+			return fmt.Errorf("dependency not added, %v", err)
 		}
 		if dep.Type == dependency.UnknownType {
 			fmt.Fprintf(out, "Could not determine source type, setting as %q", dependency.UnknownType)
@@ -82,7 +98,74 @@ func init() {
 	// is called directly, e.g.:
 	// addCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	addCmd.Flags().StringVar(&mask, "mask", "", "a regex to match 'version', leave blank to match any version")
-	addCmd.Flags().StringVar(&sourceType, "type", "", "source type, leave empty to autodetect (options \"github\"|\"docker\"|\"manual\")")
+	addCmd.Flags().StringVar(&source, "source", "", "where to read github, gitlab and gitea versions from, leave empty to use releases and fallback to tags (options \"releases\"|\"tags\"|\"both\")")
+	addCmd.Flags().StringVar(&constraint, "constraint", "", "a semver range to match 'version', ie. \"^1.17\" or \">=1.16 <2\", can be combined with --mask")
+	addCmd.Flags().StringVar(&prerelease, "prerelease", "", "how to treat pre-release versions, leave empty to exclude them (options \"exclude\"|\"include\"|\"only\")")
+	addCmd.Flags().StringVar(&updatePolicy, "update-policy", "", fmt.Sprintf("the largest kind of update to track relative to 'version', leave empty to track any version (options %s)", options(fetcher.ValidUpdatePolicies)))
+	addCmd.Flags().StringVar(&depMinAge, "min-age", "", "only propose versions published at least this long ago, ie. \"7d\" or \"36h\", leave empty to use the global setting")
+	addCmd.Flags().StringVar(&sourceType, "type", "", fmt.Sprintf("source type, leave empty to autodetect (options %s)", options(dependency.ValidTypes(fetchers))))
+}
+
+// sourceTypes are the types of projects on a forge, they can read versions from releases or tags
+var sourceTypes = []string{dependency.GithubType, dependency.GitLabType, dependency.GiteaType, dependency.BitbucketType}
+
+// validateSpec checks the settings of a dependency are supported by its type
+func validateSpec(dep dependency.Spec) error {
+	if dep.Mask != "" {
+		if _, err := regexp.Compile(fmt.Sprintf("^%s$", dep.Mask)); err != nil {
+			return fmt.Errorf("%q is not a valid mask: %v", dep.Mask, err)
+		}
+	}
+	if dep.Source != "" {
+		if !stringInSlice(dep.Type, sourceTypes) {
+			return fmt.Errorf("--source is only supported for the %s types", options(sourceTypes))
+		}
+		if !stringInSlice(dep.Source, fetcher.ValidSources) {
+			return fmt.Errorf("%q is not a valid source", dep.Source)
+		}
+		if dep.Type == dependency.BitbucketType && dep.Source != fetcher.SourceTags {
+			return fmt.Errorf("%q only has tags", dependency.BitbucketType)
+		}
+	}
+	if dep.Constraint != "" {
+		if _, err := versioned.ParseConstraint(dep.Constraint); err != nil {
+			return err
+		}
+	}
+	if dep.Prerelease != "" && !stringInSlice(dep.Prerelease, fetcher.ValidPrereleasePolicies) {
+		return fmt.Errorf("%q is not a valid prerelease policy", dep.Prerelease)
+	}
+	if dep.UpdatePolicy != "" {
+		if !stringInSlice(dep.UpdatePolicy, fetcher.ValidUpdatePolicies) {
+			return fmt.Errorf("%q is not a valid update policy", dep.UpdatePolicy)
+		}
+		if dep.UpdatePolicy == fetcher.UpdatePolicyPatch || dep.UpdatePolicy == fetcher.UpdatePolicyMinor {
+			if _, err := versioned.ParseSemver(dep.Version); err != nil {
+				return fmt.Errorf("update policy %q requires a semantic version: %v", dep.UpdatePolicy, err)
+			}
+		}
+	}
+	if dep.MinAge != "" {
+		if _, err := dependency.ParseAge(dep.MinAge); err != nil {
+			return fmt.Errorf("%q is not a valid minimum age", dep.MinAge)
+		}
+	}
+	for _, pattern := range dep.Ignore {
+		if _, err := regexp.Compile(fmt.Sprintf("^%s$", pattern)); err != nil {
+			return fmt.Errorf("%q is not a valid ignore pattern: %v", pattern, err)
+		}
+	}
+	if dep.SnoozeUntil != "" {
+		if _, err := dependency.ParseSnoozeUntil(dep.SnoozeUntil); err != nil {
+			return err
+		}
+	}
+	if dep.Timeout != "" {
+		if timeout, err := time.ParseDuration(dep.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("%q is not a valid timeout", dep.Timeout)
+		}
+	}
+	return nil
 }
 
 func stringInSlice(a string, list []string) bool {
@@ -93,3 +176,12 @@ func stringInSlice(a string, list []string) bool {
 	}
 	return false
 }
+
+// options formats a list of flag values for the help text, ie. "github"|"docker"
+func options(list []string) string {
+	quoted := make([]string, 0, len(list))
+	for _, o := range list {
+		quoted = append(quoted, fmt.Sprintf("%q", o))
+	}
+	return strings.Join(quoted, "|")
+}
@@ -0,0 +1,85 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/spf13/cobra"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply [name|hash...]",
+	Short: "Write the versions of dependencies to their locations",
+	Long: `Write the version of each dependency to the files in its 'locations', ie. after accepting updates with 'gofer update'.
+A location is a file with a regex that matches the version, a YAML path to the value or both.
+Only the matched versions are changed, the rest of the files keep their formatting.
+Select the dependencies by name or hash, all of the dependencies with locations are selected when none are passed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mngr := manager.NewFileManager(cfgFile)
+		manifest, err := mngr.Read()
+		if err != nil {
+			return err
+		}
+		selected := make(map[string]bool)
+		for _, ref := range args {
+			i, err := manifest.Find(ref)
+			if err != nil {
+				return fmt.Errorf("no files changed, %v", err)
+			}
+			selected[manifest.Dependencies[i].Name] = true
+		}
+		changes, err := manifest.Apply(filepath.Dir(cfgFile), func(dep dependency.Spec) bool {
+			return len(args) == 0 || selected[dep.Name]
+		}, ioutil.ReadFile)
+		if err != nil {
+			return fmt.Errorf("no files changed, %v", err)
+		}
+
+		if len(changes) == 0 {
+			fmt.Fprintln(out, "All of the locations are up to date")
+			return nil
+		}
+		for _, change := range changes {
+			fmt.Fprint(out, change.Diff())
+		}
+		if dryRun {
+			return nil
+		}
+		for _, change := range changes {
+			info, err := os.Stat(change.File)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(change.File, change.After, info.Mode()); err != nil {
+				return fmt.Errorf("could not write %q: %v", change.File, err)
+			}
+		}
+		fmt.Fprintf(out, "Updated %d files\n", len(changes))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "don't change any files, just print the changes")
+}
@@ -0,0 +1,48 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of registry and API responses",
+	Long: `Responses from the registries and APIs are cached on disk and revalidated with conditional requests.
+The cache is stored in the 'gofer' directory of the user's cache directory, set --cache-dir or GOFER_CACHE_DIR to change it.`,
+}
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all of the cached responses",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := transport.DefaultCache.Clear(); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Cleared the cache in %q\n", transport.DefaultCache.Dir)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
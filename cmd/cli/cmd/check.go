@@ -0,0 +1,114 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+	"github.com/spf13/cobra"
+)
+
+// exit codes of the check command, 1 is used for all other errors
+const (
+	exitUpdatesAvailable = 2
+	exitLookupErrors     = 3
+)
+
+var failOn string
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check if any of the dependencies from the 'config.yaml' file are outdated",
+	Long: `Fetch the latest versions of the dependencies without writing the config file, for use in CI pipelines.
+
+Exits with:
+  0 when all of the dependencies are current
+  2 when updates are available
+  3 when the latest version of a dependency could not be retrieved
+  1 for any other error
+
+Use --fail-on to only fail on updates of at least a 'major', 'minor' or 'patch' version.
+Versions that are not semantic versions could be any kind of update and always fail.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !stringInSlice(failOn, versioned.ValidUpdateKinds) {
+			return fmt.Errorf("%q is not a valid update kind", failOn)
+		}
+
+		mngr := manager.NewFileManager(cfgFile)
+		manifest, err := mngr.Read()
+		if err != nil {
+			return err
+		}
+		opts, err := latestOptions()
+		if err != nil {
+			return err
+		}
+		updatedManifest, err := manifest.Latest(cmd.Context(), fetchers, opts)
+		if err != nil {
+			return err
+		}
+
+		var updates, failures int
+		for _, dep := range updatedManifest.Dependencies {
+			if len(types) > 0 && !stringInSlice(dep.Type, types) {
+				continue
+			}
+			if dep.LookupFailed() {
+				failures++
+				fmt.Fprintf(errOut, "%s: %s\n", dep.Name, dep.Notes)
+				continue
+			}
+			if !dep.Outdated() {
+				continue
+			}
+			if !versioned.UpdateKindAtLeast(dep.UpdateKind, failOn) {
+				continue
+			}
+			updates++
+			if dep.UpdateKind == "" {
+				fmt.Fprintf(out, "%s: %s -> %s\n", dep.Name, dep.Version, dep.LatestVersion)
+			} else {
+				fmt.Fprintf(out, "%s: %s -> %s (%s)\n", dep.Name, dep.Version, dep.LatestVersion, dep.UpdateKind)
+			}
+		}
+
+		// don't print the usage or an error, the exit code is the result
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		switch {
+		case failures > 0:
+			return &exitError{code: exitLookupErrors}
+		case updates > 0:
+			return &exitError{code: exitUpdatesAvailable}
+		}
+		fmt.Fprintln(out, "All of the dependencies are current")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().StringSliceVar(&types, "types", []string{}, fmt.Sprintf("source type(s), leave empty to check all (options %s)", options(dependency.ValidTypes(fetchers))))
+	checkCmd.Flags().StringVar(&failOn, "fail-on", versioned.UpdatePatch, fmt.Sprintf("the smallest kind of update to fail on (options %s)", options(versioned.ValidUpdateKinds)))
+	checkCmd.Flags().IntVar(&concurrency, "concurrency", dependency.DefaultConcurrency, "number of dependencies to fetch at the same time")
+	checkCmd.Flags().DurationVar(&timeout, "timeout", dependency.DefaultTimeout, "how long to wait for the latest version of each dependency, can be overridden with a dependency's 'timeout'")
+	checkCmd.Flags().IntVar(&hostConcurrency, "host-concurrency", dependency.DefaultHostConcurrency, "number of dependencies to fetch at the same time from a single registry or API host")
+	checkCmd.Flags().StringVar(&minAge, "min-age", "", "only propose versions published at least this long ago, ie. \"7d\" or \"36h\", can be overridden with a dependency's 'minAge'")
+}
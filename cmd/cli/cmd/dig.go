@@ -16,12 +16,19 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/dkoshkin/gofer/pkg/dependency"
 	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/dkoshkin/gofer/pkg/transport"
 	"github.com/spf13/cobra"
 )
 
 var dryRun bool
+var concurrency int
+var hostConcurrency int
+var timeout time.Duration
+var minAge string
 
 // digCmd represents the dig command
 var digCmd = &cobra.Command{
@@ -35,12 +42,17 @@ var digCmd = &cobra.Command{
 			return err
 		}
 
-		updatedManifest, err := manifest.Latest()
+		opts, err := latestOptions()
+		if err != nil {
+			return err
+		}
+		updatedManifest, err := manifest.Latest(cmd.Context(), fetchers, opts)
 		if err != nil {
 			return err
 		}
 
-		writeManifest(updatedManifest, output, false, []string{})
+		writeManifest(updatedManifest, output, dependency.FilterOptions{})
+		writeRateLimits(transport.DefaultRateLimits.All())
 
 		if !dryRun {
 			if err := mngr.Write(*updatedManifest); err != nil {
@@ -65,4 +77,28 @@ func init() {
 	// is called directly, e.g.:
 	digCmd.Flags().StringVarP(&output, "output", "o", "yaml", "output format to print to stdout (options \"table\"|\"yaml\"|\"json\")")
 	digCmd.Flags().BoolVar(&dryRun, "dry-run", false, "don't overwrite the config file, just print to stdout")
+	digCmd.Flags().IntVar(&concurrency, "concurrency", dependency.DefaultConcurrency, "number of dependencies to fetch at the same time")
+	digCmd.Flags().DurationVar(&timeout, "timeout", dependency.DefaultTimeout, "how long to wait for the latest version of each dependency, can be overridden with a dependency's 'timeout'")
+	digCmd.Flags().IntVar(&hostConcurrency, "host-concurrency", dependency.DefaultHostConcurrency, "number of dependencies to fetch at the same time from a single registry or API host")
+	digCmd.Flags().StringVar(&minAge, "min-age", "", "only propose versions published at least this long ago, ie. \"7d\" or \"36h\", can be overridden with a dependency's 'minAge'")
+}
+
+// latestOptions returns the options for retrieving the latest versions from the flags
+func latestOptions() (dependency.LatestOptions, error) {
+	age, err := dependency.ParseAge(minAge)
+	if err != nil {
+		return dependency.LatestOptions{}, err
+	}
+	return dependency.LatestOptions{Concurrency: concurrency, HostConcurrency: hostConcurrency, Timeout: timeout, MinAge: age}, nil
+}
+
+// writeRateLimits prints the remaining rate limit budget of each host to stderr
+func writeRateLimits(limits []transport.RateLimit) {
+	for _, limit := range limits {
+		fmt.Fprintf(errOut, "Rate limit for %s: %d/%d remaining", limit.Host, limit.Remaining, limit.Limit)
+		if !limit.Reset.IsZero() {
+			fmt.Fprintf(errOut, ", resets at %s", limit.Reset.Format(time.RFC3339))
+		}
+		fmt.Fprintln(errOut)
+	}
 }
@@ -0,0 +1,110 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/spf13/cobra"
+)
+
+var depVersion string
+var depTimeout string
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:     "edit name|hash",
+	Aliases: []string{"set"},
+	Args:    cobra.ExactArgs(1),
+	Short:   "Change a dependency in your config file",
+	Long: `Change the version, type, mask or other settings of a dependency in your config file.
+The dependency is selected by its name or hash, a prefix of the hash is enough.
+Only the settings that are passed are changed, pass an empty value to unset one, ie. --mask "".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mngr := manager.NewFileManager(cfgFile)
+		manifest, err := mngr.Read()
+		if err != nil {
+			return err
+		}
+		i, err := manifest.Find(args[0])
+		if err != nil {
+			return fmt.Errorf("dependency not changed, %v", err)
+		}
+
+		dep := manifest.Dependencies[i]
+		flags := cmd.Flags()
+		if flags.Changed("version") {
+			dep.Version = depVersion
+		}
+		if flags.Changed("type") {
+			if sourceType != "" && !stringInSlice(sourceType, dependency.ValidTypes(fetchers)) {
+				return fmt.Errorf("dependency not changed, %q is not a valid type", sourceType)
+			}
+			dep.Type = sourceType
+		}
+		if flags.Changed("mask") {
+			dep.Mask = mask
+		}
+		if flags.Changed("constraint") {
+			dep.Constraint = constraint
+		}
+		if flags.Changed("source") {
+			dep.Source = source
+		}
+		if flags.Changed("prerelease") {
+			dep.Prerelease = prerelease
+		}
+		if flags.Changed("update-policy") {
+			dep.UpdatePolicy = updatePolicy
+		}
+		if flags.Changed("min-age") {
+			dep.MinAge = depMinAge
+		}
+		if flags.Changed("timeout") {
+			dep.Timeout = depTimeout
+		}
+		// validate with the detected type when it's not set
+		validated := dep
+		validated.Type = dep.TypeFrom(fetchers)
+		if err := validateSpec(validated); err != nil {
+			return fmt.Errorf("dependency not changed, %v", err)
+		}
+
+		dep.UpdateKind = dep.ClassifyUpdate()
+		manifest.Dependencies[i] = dep
+		if err := mngr.Write(*manifest); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Changed %q\n", dep.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+
+	editCmd.Flags().StringVar(&depVersion, "version", "", "the current version of the dependency")
+	editCmd.Flags().StringVar(&mask, "mask", "", "a regex to match 'version', set to empty to match any version")
+	editCmd.Flags().StringVar(&source, "source", "", "where to read github, gitlab and gitea versions from, set to empty to use releases and fallback to tags (options \"releases\"|\"tags\"|\"both\")")
+	editCmd.Flags().StringVar(&constraint, "constraint", "", "a semver range to match 'version', ie. \"^1.17\" or \">=1.16 <2\", can be combined with --mask")
+	editCmd.Flags().StringVar(&prerelease, "prerelease", "", "how to treat pre-release versions, set to empty to exclude them (options \"exclude\"|\"include\"|\"only\")")
+	editCmd.Flags().StringVar(&updatePolicy, "update-policy", "", fmt.Sprintf("the largest kind of update to track relative to 'version', set to empty to track any version (options %s)", options(fetcher.ValidUpdatePolicies)))
+	editCmd.Flags().StringVar(&depMinAge, "min-age", "", "only propose versions published at least this long ago, ie. \"7d\" or \"36h\", set to empty to use the global setting")
+	editCmd.Flags().StringVar(&depTimeout, "timeout", "", "how long to wait for the latest version, ie. \"30s\", set to empty to use the default")
+	editCmd.Flags().StringVar(&sourceType, "type", "", fmt.Sprintf("source type, set to empty to autodetect (options %s)", options(dependency.ValidTypes(fetchers))))
+}
@@ -0,0 +1,85 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/spf13/cobra"
+)
+
+var unignore bool
+
+// ignoreCmd represents the ignore command
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore name|hash version...",
+	Args:  cobra.MinimumNArgs(2),
+	Short: "Never propose specific versions of a dependency",
+	Long: `Add versions or regex patterns to the ignore list of a dependency, ie. "1.17.4" or "1.18.*".
+Ignored versions are never proposed as the latest version and are not outdated.
+The dependency is selected by its name or hash, a prefix of the hash is enough.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mngr := manager.NewFileManager(cfgFile)
+		manifest, err := mngr.Read()
+		if err != nil {
+			return err
+		}
+		i, err := manifest.Find(args[0])
+		if err != nil {
+			return fmt.Errorf("dependency not changed, %v", err)
+		}
+
+		dep := manifest.Dependencies[i]
+		for _, pattern := range args[1:] {
+			if unignore {
+				dep.Ignore = removeString(dep.Ignore, pattern)
+			} else if !stringInSlice(pattern, dep.Ignore) {
+				dep.Ignore = append(dep.Ignore, pattern)
+			}
+		}
+		if err := validateSpec(dep); err != nil {
+			return fmt.Errorf("dependency not changed, %v", err)
+		}
+
+		manifest.Dependencies[i] = dep
+		if err := mngr.Write(*manifest); err != nil {
+			return err
+		}
+		if unignore {
+			fmt.Fprintf(out, "No longer ignoring %v for %q\n", args[1:], dep.Name)
+		} else {
+			fmt.Fprintf(out, "Ignoring %v for %q\n", args[1:], dep.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ignoreCmd)
+
+	ignoreCmd.Flags().BoolVar(&unignore, "remove", false, "remove the versions from the ignore list instead")
+}
+
+// removeString returns the list without any of the occurrences of a
+func removeString(list []string, a string) []string {
+	var kept []string
+	for _, b := range list {
+		if b != a {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
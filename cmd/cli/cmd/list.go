@@ -20,6 +20,7 @@ import (
 
 	"github.com/dkoshkin/gofer/pkg/dependency"
 	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/dkoshkin/gofer/pkg/versioned"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +28,7 @@ var outputTypes = []string{"table", "yaml", "json"}
 var output string
 var outdated bool
 var types []string
+var updateKinds []string
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
@@ -42,6 +44,11 @@ var listCmd = &cobra.Command{
 		if !validOutputType {
 			return fmt.Errorf("output %q is not valid", output)
 		}
+		for _, kind := range updateKinds {
+			if !stringInSlice(kind, versioned.ValidUpdateKinds) {
+				return fmt.Errorf("%q is not a valid update kind", kind)
+			}
+		}
 
 		// read config file and print dependencies
 		mngr := manager.NewFileManager(cfgFile)
@@ -49,7 +56,7 @@ var listCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		writeManifest(manifest, output, outdated, types)
+		writeManifest(manifest, output, dependency.FilterOptions{Outdated: outdated, Types: types, UpdateKinds: updateKinds})
 
 		return nil
 	},
@@ -68,15 +75,17 @@ func init() {
 	// is called directly, e.g.:
 	listCmd.Flags().StringVarP(&output, "output", "o", "yaml", "output format (options \"table\"|\"yaml\"|\"json\")")
 	listCmd.Flags().BoolVar(&outdated, "outdated", false, "only list the dependencies that have outdated versions")
-	listCmd.Flags().StringSliceVar(&types, "types", []string{}, "source type(s), leave empty to select all (options \"github\"|\"docker\"|\"manual\")")
+	listCmd.Flags().StringSliceVar(&updateKinds, "update-kinds", []string{}, fmt.Sprintf("only list the dependencies with these kind(s) of updates, leave empty to select all (options %s)", options(versioned.ValidUpdateKinds)))
+	listCmd.Flags().StringSliceVar(&types, "types", []string{}, fmt.Sprintf("source type(s), leave empty to select all (options %s)", options(dependency.ValidTypes(fetchers))))
 }
 
-func writeManifest(manifest *dependency.Manifest, outputType string, onlyOutdated bool, types []string) {
+func writeManifest(manifest *dependency.Manifest, outputType string, filter dependency.FilterOptions) {
 	mw := dependency.ManifestWriter{
 		Writer:        out,
-		FilterOptions: dependency.FilterOptions{Outdated: onlyOutdated, Types: types},
+		FilterOptions: filter,
+		Fetchers:      fetchers,
 	}
-	switch output {
+	switch outputType {
 	case "table":
 		fmt.Fprintln(out, strings.Repeat("-", 120))
 		mw.WriteTable(*manifest)
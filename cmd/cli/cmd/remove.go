@@ -0,0 +1,51 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/spf13/cobra"
+)
+
+// removeCmd represents the remove command
+var removeCmd = &cobra.Command{
+	Use:     "remove name|hash...",
+	Aliases: []string{"rm"},
+	Args:    cobra.MinimumNArgs(1),
+	Short:   "Remove dependencies from your config file",
+	Long: `Remove dependencies from your config file by their name or hash.
+A prefix of the hash is enough, nothing is removed when a name or hash matches more than one dependency.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mngr := manager.NewFileManager(cfgFile)
+		manifest, err := mngr.Read()
+		if err != nil {
+			return err
+		}
+		for _, ref := range args {
+			removed, err := manifest.Remove(ref)
+			if err != nil {
+				return fmt.Errorf("dependencies not removed, %v", err)
+			}
+			fmt.Fprintf(out, "Removed %q\n", removed.Name)
+		}
+		return mngr.Write(*manifest)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(removeCmd)
+}
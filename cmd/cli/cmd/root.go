@@ -15,9 +15,16 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/dkoshkin/gofer/pkg/dependency"
+	"github.com/dkoshkin/gofer/pkg/transport"
 	"github.com/spf13/cobra"
 )
 
@@ -31,9 +38,16 @@ type Version struct {
 }
 
 var cfgFile string
+var cacheDir string
+var cacheTTL time.Duration
+var cacheMaxAge time.Duration
+var in = os.Stdin
 var out = os.Stdout
 var errOut = os.Stderr
 
+// fetchers retrieve the versions for each of the dependency types
+var fetchers = dependency.DefaultFetchers()
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "gofer",
@@ -41,6 +55,12 @@ var rootCmd = &cobra.Command{
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	//	Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// the fetchers share the default cache
+		transport.DefaultCache.Dir = cacheDir
+		transport.DefaultCache.TTL = cacheTTL
+		transport.DefaultCache.MaxAge = cacheMaxAge
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -51,11 +71,37 @@ func Execute(version Version) {
 	rootCmd.SetVersionTemplate(string(bytes) + "\n")
 	// also need to set String to get cobra to print it
 	rootCmd.Version = version.Version
-	if err := rootCmd.Execute(); err != nil {
+
+	// cancel in-flight requests on Ctrl-C
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if exitErr, ok := err.(*exitError); ok {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitError exits with a specific code without printing an error
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "f", "./.gofer/config.yaml", "config file containing the list of dependencies")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", transport.DefaultCacheDir(), "directory to cache registry and API responses in, caching is disabled when empty")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", 0, "how long to use cached responses before revalidating them, they are always revalidated when 0")
+	rootCmd.PersistentFlags().DurationVar(&cacheMaxAge, "cache-max-age", transport.DefaultMaxAge, "how long to keep cached responses that are not used, they are kept until cleared when 0")
 }
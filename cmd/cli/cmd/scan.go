@@ -0,0 +1,112 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/dkoshkin/gofer/pkg/scan"
+	"github.com/spf13/cobra"
+)
+
+var writeScanned bool
+
+// scanCmd represents the scan command
+var scanCmd = &cobra.Command{
+	Use:   "scan [paths...]",
+	Short: "Find dependencies in Dockerfiles, compose files, Kubernetes manifests and go.mod files",
+	Long: `Find the images in Dockerfile 'FROM' lines, docker-compose and Kubernetes 'image' keys and Helm 'values.yaml' files,
+and the modules in the 'require' lines of 'go.mod' files.
+Directories are scanned recursively, the current directory is scanned when no paths are passed.
+Each image with a versioned tag and each module is proposed as a dependency with a mask that matches versions with the same format.
+The files of each dependency are recorded as its locations, so that 'gofer apply' can write new versions to them.
+The dependencies are printed, use --write to add the ones that are not already in your config file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !stringInSlice(output, outputTypes) {
+			return fmt.Errorf("output %q is not valid", output)
+		}
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		refs, err := scan.Paths(args...)
+		if err != nil {
+			return err
+		}
+		// the locations are relative to the config file
+		for i := range refs {
+			refs[i].File = relativeTo(filepath.Dir(cfgFile), refs[i].File)
+		}
+
+		if !writeScanned {
+			writeManifest(&dependency.Manifest{APIVersion: apiVersion, Dependencies: scan.Specs(refs)}, output, dependency.FilterOptions{})
+			return nil
+		}
+
+		mngr := manager.NewFileManager(cfgFile)
+		manifest, err := mngr.Read()
+		if err != nil {
+			return err
+		}
+		added := make([]dependency.Spec, 0)
+		for _, dep := range scan.Specs(refs) {
+			if manifest.Append(dep) {
+				added = append(added, dep)
+			}
+		}
+		if len(added) == 0 {
+			fmt.Fprintln(out, "No new dependencies found")
+			return nil
+		}
+		if err := mngr.Write(*manifest); err != nil {
+			return err
+		}
+		for _, dep := range added {
+			files := make([]string, 0, len(dep.Locations))
+			for _, location := range dep.Locations {
+				files = append(files, location.File)
+			}
+			fmt.Fprintf(out, "Added %q %s from %s\n", dep.Name, dep.Version, strings.Join(files, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+
+	scanCmd.Flags().BoolVar(&writeScanned, "write", false, "add the dependencies that are not already in the config file")
+	scanCmd.Flags().StringVarP(&output, "output", "o", "yaml", "output format to print the dependencies when not writing them (options \"table\"|\"yaml\"|\"json\")")
+}
+
+// relativeTo returns the path relative to the directory, or the absolute path when it can't be
+func relativeTo(dir, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return abs
+	}
+	rel, err := filepath.Rel(absDir, abs)
+	if err != nil {
+		return abs
+	}
+	return rel
+}
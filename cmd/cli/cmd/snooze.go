@@ -0,0 +1,89 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/spf13/cobra"
+)
+
+var snoozeUntil string
+var snoozeFor string
+var clearSnooze bool
+
+// snoozeCmd represents the snooze command
+var snoozeCmd = &cobra.Command{
+	Use:   "snooze name|hash",
+	Args:  cobra.ExactArgs(1),
+	Short: "Hide the updates of a dependency for a while",
+	Long: `Hide the available updates of a dependency until a date, ie. --until 2020-10-01, or for a duration, ie. --for 14d.
+Snoozed dependencies are not outdated, the latest version is still looked up and shown.
+The dependency is selected by its name or hash, a prefix of the hash is enough.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mngr := manager.NewFileManager(cfgFile)
+		manifest, err := mngr.Read()
+		if err != nil {
+			return err
+		}
+		i, err := manifest.Find(args[0])
+		if err != nil {
+			return fmt.Errorf("dependency not changed, %v", err)
+		}
+
+		dep := manifest.Dependencies[i]
+		switch {
+		case clearSnooze:
+			dep.SnoozeUntil = ""
+		case snoozeUntil != "" && snoozeFor != "":
+			return fmt.Errorf("dependency not changed, only one of --until or --for can be set")
+		case snoozeUntil != "":
+			if _, err := dependency.ParseSnoozeUntil(snoozeUntil); err != nil {
+				return fmt.Errorf("dependency not changed, %v", err)
+			}
+			dep.SnoozeUntil = snoozeUntil
+		case snoozeFor != "":
+			duration, err := dependency.ParseAge(snoozeFor)
+			if err != nil || duration <= 0 {
+				return fmt.Errorf("dependency not changed, %q is not a valid duration", snoozeFor)
+			}
+			dep.SnoozeUntil = time.Now().Add(duration).UTC().Format(time.RFC3339)
+		default:
+			return fmt.Errorf("dependency not changed, one of --until, --for or --clear must be set")
+		}
+
+		manifest.Dependencies[i] = dep
+		if err := mngr.Write(*manifest); err != nil {
+			return err
+		}
+		if dep.SnoozeUntil == "" {
+			fmt.Fprintf(out, "No longer snoozing %q\n", dep.Name)
+		} else {
+			fmt.Fprintf(out, "Snoozing %q until %s\n", dep.Name, dep.SnoozeUntil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snoozeCmd)
+
+	snoozeCmd.Flags().StringVar(&snoozeUntil, "until", "", "hide updates until the date, ie. \"2020-10-01\" or \"2020-10-01T12:00:00Z\"")
+	snoozeCmd.Flags().StringVar(&snoozeFor, "for", "", "hide updates for the duration, ie. \"14d\" or \"12h\"")
+	snoozeCmd.Flags().BoolVar(&clearSnooze, "clear", false, "show the updates again")
+}
@@ -0,0 +1,131 @@
+// Copyright © 2018 Dimitri Koshkin
+//
+// Licensed under the Apache License, String 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+	"github.com/dkoshkin/gofer/pkg/dependency/manager"
+	"github.com/spf13/cobra"
+)
+
+var updateAll bool
+var updateTypes []string
+var interactive bool
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update [name...]",
+	Short: "Set the version of dependencies to their latest version",
+	Long: `Set the version of dependencies to the latest version found by 'gofer dig'.
+Select the dependencies by name, by type with --type or all of them with --all.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && len(updateTypes) == 0 && !updateAll {
+			return fmt.Errorf("specify the names of the dependencies to update, --type or --all")
+		}
+		for _, t := range updateTypes {
+			if !stringInSlice(t, dependency.ValidTypes(fetchers)) {
+				return fmt.Errorf("%q is not a valid type", t)
+			}
+		}
+
+		mngr := manager.NewFileManager(cfgFile)
+		manifest, err := mngr.Read()
+		if err != nil {
+			return err
+		}
+		for _, name := range args {
+			if !hasDependency(manifest, name) {
+				return fmt.Errorf("%q is not in the config file", name)
+			}
+		}
+
+		reader := bufio.NewReader(in)
+		updates := manifest.Update(func(dep dependency.Spec) bool {
+			if len(args) > 0 && !stringInSlice(dep.Name, args) {
+				return false
+			}
+			if len(updateTypes) > 0 && !stringInSlice(dep.TypeFrom(fetchers), updateTypes) {
+				return false
+			}
+			// snoozed and ignored updates are only applied to the dependencies that were named
+			if len(args) == 0 && !dep.Outdated() {
+				return false
+			}
+			if interactive {
+				return confirm(reader, fmt.Sprintf("Update %s from %s to %s?", dep.Name, dep.Version, dep.LatestVersion))
+			}
+			return true
+		})
+
+		writeUpdates(updates, dryRun)
+		if dryRun || len(updates) == 0 {
+			return nil
+		}
+		if err := mngr.Write(*manifest); err != nil {
+			return fmt.Errorf("error trying to write out config file: %v", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().BoolVar(&updateAll, "all", false, "update all of the outdated dependencies")
+	updateCmd.Flags().StringSliceVar(&updateTypes, "type", []string{}, fmt.Sprintf("only update dependencies of the source type(s) (options %s)", options(dependency.ValidTypes(fetchers))))
+	updateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "don't overwrite the config file, just print what would be updated")
+	updateCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "confirm each update")
+}
+
+func hasDependency(manifest *dependency.Manifest, name string) bool {
+	for _, dep := range manifest.Dependencies {
+		if dep.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// confirm asks a yes or no question, anything other than 'y' or 'yes' is a no
+func confirm(reader *bufio.Reader, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := reader.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// writeUpdates prints a summary of the updated dependencies
+func writeUpdates(updates []dependency.Update, dryRun bool) {
+	if len(updates) == 0 {
+		fmt.Fprintln(out, "No dependencies were updated")
+		return
+	}
+	verb := "Updated"
+	if dryRun {
+		verb = "Would update"
+	}
+	fmt.Fprintf(out, "%s %d dependencies:\n", verb, len(updates))
+	for _, update := range updates {
+		fmt.Fprintf(out, "  %s: %s -> %s\n", update.Name, update.From, update.To)
+	}
+}
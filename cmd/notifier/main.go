@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/dkoshkin/gofer/pkg/dependency"
 	"github.com/dkoshkin/gofer/pkg/dependency/manager"
 	"github.com/dkoshkin/gofer/pkg/notifier"
+	"github.com/dkoshkin/gofer/pkg/versioned"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"os"
@@ -31,9 +33,15 @@ const (
 	notifierSenderEmailEnv = "NOTIFIER_SENDER_EMAIL"
 	notifierSubjectEnv     = "NOTIFIER_SUBJECT"
 	notifierContactsEnv    = "NOTIFIER_CONTACTS"
+	// optional, only notify on these kinds of updates, ie. "minor,patch"
+	notifierUpdateKindsEnv = "NOTIFIER_UPDATE_KINDS"
 )
 
 func main() {
+	// fail at startup instead of silently filtering out every update
+	if _, err := updateKinds(); err != nil {
+		log.Fatal(err)
+	}
 	http.HandleFunc("/", handler)
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -52,7 +60,7 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updated, err := run(&manifest)
+	updated, err := run(r.Context(), &manifest)
 	if err != nil {
 		http.Error(w, fmt.Errorf("got an error: %v", err).Error(), http.StatusInternalServerError)
 		return
@@ -68,7 +76,7 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	w.Write(js)
 }
 
-func run(manifest *dependency.Manifest) (*dependency.Manifest, error) {
+func run(ctx context.Context, manifest *dependency.Manifest) (*dependency.Manifest, error) {
 	projectID, collection, doc, err := checkDatastoreEnvs()
 	if err != nil {
 		return nil, fmt.Errorf("error reading env: %v", err)
@@ -77,6 +85,10 @@ func run(manifest *dependency.Manifest) (*dependency.Manifest, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading env: %v", err)
 	}
+	kinds, err := updateKinds()
+	if err != nil {
+		return nil, fmt.Errorf("error reading env: %v", err)
+	}
 
 	var rw manager.ReadWriter
 	credentialsBase64Bytes := os.Getenv(datastoreCredentialsBase64Env)
@@ -98,12 +110,14 @@ func run(manifest *dependency.Manifest) (*dependency.Manifest, error) {
 		return nil, fmt.Errorf("error initializing dependencies: %v", err)
 	}
 
-	newDependencies, updatedDependencies, existingDependencies, err := findDifferences(rw)
+	newDependencies, updatedDependencies, existingDependencies, err := findDifferences(ctx, rw)
 	if err != nil {
 		return nil, err
 	}
 
-	err = notifier.NewEmailNotifier(sendgridAPIKey, notifierSenderName, notifierSenderEmail, notifierSubject, contacts).Send(newDependencies, updatedDependencies)
+	// all of the updates are stored, only the selected kinds are sent
+	notifyDependencies := dependency.FilterOptions{UpdateKinds: kinds}.Filter(updatedDependencies)
+	err = notifier.NewEmailNotifier(sendgridAPIKey, notifierSenderName, notifierSenderEmail, notifierSubject, contacts).Send(newDependencies, notifyDependencies)
 	if err != nil {
 		return nil, fmt.Errorf("error sending with notifier: %v", err)
 	}
@@ -165,7 +179,26 @@ func checkNotifierEnvs() (sendgridAPIKey string, notifierSenderName string, noti
 	return
 }
 
-func findDifferences(rw manager.ReadWriter) ([]dependency.Spec, []dependency.Spec, []dependency.Spec, error) {
+// updateKinds returns the kinds of updates to notify on, an error is returned for unknown kinds
+func updateKinds() ([]string, error) {
+	kinds := make([]string, 0)
+	for _, kind := range strings.Split(os.Getenv(notifierUpdateKindsEnv), ",") {
+		if kind = strings.TrimSpace(kind); kind == "" {
+			continue
+		}
+		valid := false
+		for _, validKind := range versioned.ValidUpdateKinds {
+			valid = valid || kind == validKind
+		}
+		if !valid {
+			return nil, fmt.Errorf("%q in %s is not a valid update kind, use one of %s", kind, notifierUpdateKindsEnv, strings.Join(versioned.ValidUpdateKinds, ", "))
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+func findDifferences(ctx context.Context, rw manager.ReadWriter) ([]dependency.Spec, []dependency.Spec, []dependency.Spec, error) {
 	manifest, err := rw.Read()
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("error reading from datastore: %v", err)
@@ -175,9 +208,9 @@ func findDifferences(rw manager.ReadWriter) ([]dependency.Spec, []dependency.Spe
 		return nil, nil, nil, err
 	}
 
-	updatedManifest, err := manifest.Latest()
+	updatedManifest, err := manifest.Latest(ctx, dependency.DefaultFetchers(), dependency.LatestOptions{})
 	if err != nil {
-		fmt.Errorf("error getting updating dependencies: %v", err)
+		return nil, nil, nil, fmt.Errorf("error getting updating dependencies: %v", err)
 	}
 
 	_, updatedDependenciesMap, err := updatedManifest.ToMap()
@@ -192,7 +225,7 @@ func findDifferences(rw manager.ReadWriter) ([]dependency.Spec, []dependency.Spe
 	for k, v := range dependenciesMap {
 		if dependency, ok := updatedDependenciesMap[k]; !ok {
 			newDependencies = append(newDependencies, v)
-		} else if v.Version != dependency.LatestVersion {
+		} else if dependency.Outdated() {
 			updatedDependencies = append(updatedDependencies, dependency)
 		} else {
 			existingDependencies = append(existingDependencies, dependency)
@@ -0,0 +1,59 @@
+package dependency
+
+import (
+	"sync"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/fetcher/bitbucket"
+	"github.com/dkoshkin/gofer/pkg/fetcher/crates"
+	"github.com/dkoshkin/gofer/pkg/fetcher/docker"
+	"github.com/dkoshkin/gofer/pkg/fetcher/git"
+	"github.com/dkoshkin/gofer/pkg/fetcher/gitea"
+	"github.com/dkoshkin/gofer/pkg/fetcher/github"
+	"github.com/dkoshkin/gofer/pkg/fetcher/gitlab"
+	"github.com/dkoshkin/gofer/pkg/fetcher/gomod"
+	"github.com/dkoshkin/gofer/pkg/fetcher/helm"
+	"github.com/dkoshkin/gofer/pkg/fetcher/npm"
+	"github.com/dkoshkin/gofer/pkg/fetcher/pypi"
+	"github.com/dkoshkin/gofer/pkg/fetcher/terraform"
+)
+
+var (
+	builtin     *fetcher.Registry
+	builtinOnce sync.Once
+)
+
+// builtinFetchers returns the registry used to determine the type when a registry is not provided
+// It is only built the first time a type is determined without one, the fetchers read the environment
+func builtinFetchers() *fetcher.Registry {
+	builtinOnce.Do(func() {
+		builtin = DefaultFetchers()
+	})
+	return builtin
+}
+
+// DefaultFetchers returns a registry with all of the built-in dependency types
+// Custom types can be registered on the returned registry before passing it to Manifest.Latest
+func DefaultFetchers() *fetcher.Registry {
+	fetchers := fetcher.NewRegistry()
+	fetchers.Register(GithubType, github.New())
+	// the public hosts and the self-hosted instances in GOFER_GITLAB_HOSTS, GOFER_GITEA_HOSTS and GOFER_BITBUCKET_HOSTS are detected
+	fetchers.Register(GitLabType, gitlab.New())
+	fetchers.Register(GiteaType, gitea.New())
+	fetchers.Register(BitbucketType, bitbucket.New())
+	// any other HTTP URL ending with '.git', the forges above are detected first
+	fetchers.Register(GitType, git.New())
+	// module paths are not detected, many of them are also Github URLs
+	fetchers.Register(GoModType, gomod.New())
+	// packages are only detected by the URL of their page, ie. 'https://www.npmjs.com/package/react'
+	fetchers.Register(NpmType, npm.New())
+	fetchers.Register(PyPIType, pypi.New())
+	fetchers.Register(CratesType, crates.New())
+	// only OCI charts are detected, chart repositories are regular URLs
+	fetchers.Register(HelmType, helm.New())
+	// only names with the public registry hostname are detected, others look like images
+	fetchers.Register(TerraformType, terraform.New())
+	// any name that is not recognized is assumed to be an image
+	fetchers.RegisterFallback(DockerType, docker.New())
+	return fetchers
+}
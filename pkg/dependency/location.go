@@ -0,0 +1,229 @@
+package dependency
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Location is where the version of a dependency is written in a file
+// Regex selects the version in the file, or in the value at YAMLPath when both are set
+type Location struct {
+	// File is relative to the directory of the manifest, absolute paths are used as is
+	File string `yaml:"file" json:"file"`
+	// Regex matches the version, only the first capture group is replaced when it has one, ie. "busybox:([0-9.]+)"
+	Regex string `yaml:"regex,omitempty" json:"regex,omitempty"`
+	// YAMLPath is the dot separated path to a value in every YAML document of the file, ie. "image.tag" or "spec.containers.0.image"
+	YAMLPath string `yaml:"yamlPath,omitempty" json:"yamlPath,omitempty"`
+}
+
+// Rewrite returns the contents of the file with the version written at the location
+// Only the selected text is changed, everything else keeps its formatting
+func (l Location) Rewrite(data []byte, version string) ([]byte, error) {
+	if l.Regex == "" && l.YAMLPath == "" {
+		return nil, fmt.Errorf("location in %q needs a regex or a YAML path", l.File)
+	}
+	var rgx *regexp.Regexp
+	if l.Regex != "" {
+		var err error
+		if rgx, err = regexp.Compile(l.Regex); err != nil {
+			return nil, fmt.Errorf("%q is not a valid regex: %v", l.Regex, err)
+		}
+	}
+	if l.YAMLPath == "" {
+		rewritten, found := replaceMatches(string(data), rgx, version)
+		if !found {
+			return nil, fmt.Errorf("%q does not match anything in %q", l.Regex, l.File)
+		}
+		return []byte(rewritten), nil
+	}
+
+	nodes, err := yamlPathNodes(data, l.YAMLPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %q: %v", l.File, err)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%q is not in %q", l.YAMLPath, l.File)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	for _, node := range nodes {
+		value := version
+		if rgx != nil {
+			var found bool
+			if value, found = replaceMatches(node.Value, rgx, version); !found {
+				return nil, fmt.Errorf("%q does not match the value of %q in %q", l.Regex, l.YAMLPath, l.File)
+			}
+		}
+		// the value of a plain or quoted scalar starts at its column, replace it where it is to keep the quotes and comments
+		line := lines[node.Line-1]
+		column := columnOffset(line, node.Column)
+		start := strings.Index(line[column:], node.Value)
+		if start < 0 || node.Value == "" {
+			return nil, fmt.Errorf("the value of %q in %q must be on a single line without escapes", l.YAMLPath, l.File)
+		}
+		start += column
+		lines[node.Line-1] = line[:start] + value + line[start+len(node.Value):]
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// columnOffset returns the byte offset of a column in the line, YAML columns count characters from 1
+func columnOffset(line string, column int) int {
+	runes := []rune(line)
+	if column < 1 || column > len(runes) {
+		return 0
+	}
+	return len(string(runes[:column-1]))
+}
+
+// replaceMatches replaces the first capture group of every match, or the whole match when there are no groups
+func replaceMatches(in string, rgx *regexp.Regexp, version string) (string, bool) {
+	matches := rgx.FindAllStringSubmatchIndex(in, -1)
+	if len(matches) == 0 {
+		return in, false
+	}
+	var out strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if len(match) > 2 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+		out.WriteString(in[last:start])
+		out.WriteString(version)
+		last = end
+	}
+	out.WriteString(in[last:])
+	return out.String(), true
+}
+
+// yamlPathNodes returns the scalar values at the path in all of the documents
+func yamlPathNodes(data []byte, path string) ([]*yaml.Node, error) {
+	nodes := make([]*yaml.Node, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		if node := yamlPath(doc.Content[0], strings.Split(path, ".")); node != nil && node.Kind == yaml.ScalarNode {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func yamlPath(node *yaml.Node, keys []string) *yaml.Node {
+	if len(keys) == 0 {
+		return node
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == keys[0] {
+				return yamlPath(node.Content[i+1], keys[1:])
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(keys[0]); err == nil && i >= 0 && i < len(node.Content) {
+			return yamlPath(node.Content[i], keys[1:])
+		}
+	}
+	return nil
+}
+
+// FileChange is the new content of a file after writing the versions at its locations
+type FileChange struct {
+	File   string
+	Before []byte
+	After  []byte
+}
+
+// Apply returns the changes to write the version of each dependency at its locations, files are read with read
+// The files are relative to dir, the directory of the manifest, and the changes have the resolved paths
+// Files that would not change are not returned, the changes are in the order the files are first referenced
+func (m Manifest) Apply(dir string, selected func(dep Spec) bool, read func(file string) ([]byte, error)) ([]FileChange, error) {
+	changes := make([]*FileChange, 0)
+	byFile := make(map[string]*FileChange)
+	for _, dep := range m.Dependencies {
+		if len(dep.Locations) == 0 || !selected(dep) {
+			continue
+		}
+		for _, location := range dep.Locations {
+			file := location.File
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			change, ok := byFile[file]
+			if !ok {
+				data, err := read(file)
+				if err != nil {
+					return nil, err
+				}
+				change = &FileChange{File: file, Before: data, After: data}
+				byFile[file] = change
+				changes = append(changes, change)
+			}
+			after, err := location.Rewrite(change.After, dep.Version)
+			if err != nil {
+				return nil, fmt.Errorf("could not write the version of %q: %v", dep.Name, err)
+			}
+			change.After = after
+		}
+	}
+	changed := make([]FileChange, 0, len(changes))
+	for _, change := range changes {
+		if !bytes.Equal(change.Before, change.After) {
+			changed = append(changed, *change)
+		}
+	}
+	return changed, nil
+}
+
+// Diff returns the changed lines in the unified diff format
+// Versions are replaced within lines, so lines are only compared to the line with the same number
+func (c FileChange) Diff() string {
+	before, after := strings.SplitAfter(string(c.Before), "\n"), strings.SplitAfter(string(c.After), "\n")
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", c.File, c.File)
+	if len(before) != len(after) {
+		fmt.Fprintf(&diff, "@@ -1,%d +1,%d @@\n", len(before), len(after))
+		writeLines(&diff, "-", before)
+		writeLines(&diff, "+", after)
+		return diff.String()
+	}
+	for i := range before {
+		if before[i] == after[i] {
+			continue
+		}
+		fmt.Fprintf(&diff, "@@ -%d +%d @@\n", i+1, i+1)
+		writeLines(&diff, "-", before[i:i+1])
+		writeLines(&diff, "+", after[i:i+1])
+	}
+	return diff.String()
+}
+
+func writeLines(w io.Writer, prefix string, lines []string) {
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s", prefix, line)
+		if !strings.HasSuffix(line, "\n") {
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package dependency
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		location Location
+		in       string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "regex with a group",
+			location: Location{File: "Dockerfile", Regex: `busybox:([0-9.]+)`},
+			in:       "FROM busybox:1.28.1 AS base\n# busybox:1.28.1 again\nFROM busybox-extras:1.28.1\n",
+			expected: "FROM busybox:1.29.3 AS base\n# busybox:1.29.3 again\nFROM busybox-extras:1.28.1\n",
+		},
+		{
+			name:     "regex without a group",
+			location: Location{File: "VERSION", Regex: `[0-9]+\.[0-9]+\.[0-9]+`},
+			in:       "1.28.1",
+			expected: "1.29.3",
+		},
+		{
+			name:     "regex does not match",
+			location: Location{File: "Dockerfile", Regex: `nginx:(.+)`},
+			in:       "FROM busybox:1.28.1\n",
+			err:      true,
+		},
+		{
+			name:     "yaml path keeps comments and quotes",
+			location: Location{File: "values.yaml", YAMLPath: "image.tag"},
+			in:       "image:\n  repository: busybox # the image\n  tag: \"1.28.1\"   # pinned\n  other: 1.28.1\n",
+			expected: "image:\n  repository: busybox # the image\n  tag: \"1.29.3\"   # pinned\n  other: 1.28.1\n",
+		},
+		{
+			name:     "yaml path with a regex in all documents",
+			location: Location{File: "deployment.yaml", YAMLPath: "spec.containers.1.image", Regex: `:(.+)$`},
+			in:       "spec:\n  containers:\n  - image: nginx:1.19.2\n  - {name: busybox, image: 'busybox:1.28.1'}\n---\nspec:\n  containers: [{image: nginx:1.19.2}, {image: busybox:1.28.1}]\n",
+			expected: "spec:\n  containers:\n  - image: nginx:1.19.2\n  - {name: busybox, image: 'busybox:1.29.3'}\n---\nspec:\n  containers: [{image: nginx:1.19.2}, {image: busybox:1.29.3}]\n",
+		},
+		{
+			name:     "yaml path after multi-byte characters",
+			location: Location{File: "values.yaml", YAMLPath: "image.tag"},
+			in:       "image: {description: \"説明説明説明説明1.28.1\", tag: 1.28.1}\n",
+			expected: "image: {description: \"説明説明説明説明1.28.1\", tag: 1.29.3}\n",
+		},
+		{
+			name:     "yaml path is not in the file",
+			location: Location{File: "values.yaml", YAMLPath: "image.version"},
+			in:       "image:\n  tag: 1.28.1\n",
+			err:      true,
+		},
+		{
+			name:     "no regex or yaml path",
+			location: Location{File: "values.yaml"},
+			in:       "image:\n  tag: 1.28.1\n",
+			err:      true,
+		},
+	}
+	for _, test := range tests {
+		out, err := test.location.Rewrite([]byte(test.in), "1.29.3")
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, instead got %q", test.name, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if string(out) != test.expected {
+			t.Errorf("%s: expected\n%s\ninstead got\n%s", test.name, test.expected, out)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	// the files are relative to the directory of the manifest
+	files := map[string]string{
+		"project/Dockerfile": "FROM busybox:1.28.1\nFROM nginx:1.19.2\n",
+		"/chart/values.yaml": "image:\n  tag: 1.28.1\n",
+	}
+	manifest := Manifest{
+		Dependencies: []Spec{
+			{
+				Name:    "busybox",
+				Version: "1.29.3",
+				Locations: []Location{
+					{File: "Dockerfile", Regex: `busybox:(\S+)`},
+					{File: "/chart/values.yaml", YAMLPath: "image.tag"},
+				},
+			},
+			{
+				Name:      "nginx",
+				Version:   "1.19.2",
+				Locations: []Location{{File: "Dockerfile", Regex: `nginx:(\S+)`}},
+			},
+			{
+				Name:      "alpine",
+				Version:   "3.12",
+				Locations: []Location{{File: "missing"}},
+			},
+		},
+	}
+	read := func(file string) ([]byte, error) {
+		data, ok := files[file]
+		if !ok {
+			return nil, fmt.Errorf("%q does not exist", file)
+		}
+		return []byte(data), nil
+	}
+	changes, err := manifest.Apply("project", func(dep Spec) bool { return dep.Name != "alpine" }, read)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, instead got %d", len(changes))
+	}
+	expectedDiff := `--- project/Dockerfile
++++ project/Dockerfile
+@@ -1 +1 @@
+-FROM busybox:1.28.1
++FROM busybox:1.29.3
+`
+	if diff := changes[0].Diff(); diff != expectedDiff {
+		t.Errorf("expected diff\n%s\ninstead got\n%s", expectedDiff, diff)
+	}
+	if string(changes[1].After) != "image:\n  tag: 1.29.3\n" {
+		t.Errorf("unexpected change to %q:\n%s", changes[1].File, changes[1].After)
+	}
+
+	if _, err := manifest.Apply("project", func(dep Spec) bool { return true }, read); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
@@ -3,12 +3,14 @@ package dependency
 import (
 	"gopkg.in/yaml.v3"
 
+	"context"
 	"fmt"
 	"github.com/dkoshkin/gofer/pkg/fetcher"
-	"github.com/dkoshkin/gofer/pkg/fetcher/docker"
-	"github.com/dkoshkin/gofer/pkg/fetcher/github"
+	"github.com/dkoshkin/gofer/pkg/versioned"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Manifest contains a list of dependencies
@@ -45,46 +47,278 @@ func (m *Manifest) Append(dep Spec) bool {
 	return true
 }
 
-func (m *Manifest) Latest() (*Manifest, error) {
+// Find returns the index of the dependency with the name or hash, a prefix of the hash is enough
+// An error is returned when no dependencies or more than one dependency match
+func (m *Manifest) Find(ref string) (int, error) {
+	if ref == "" {
+		return -1, fmt.Errorf("a name or hash is required")
+	}
+	matches := make([]int, 0)
+	for i, dep := range m.Dependencies {
+		hash, err := dep.Hash()
+		if err != nil {
+			return -1, err
+		}
+		if dep.Name == ref || strings.HasPrefix(hash, ref) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return -1, fmt.Errorf("%q is not in the config file", ref)
+	case 1:
+		return matches[0], nil
+	}
+	descriptions := make([]string, 0, len(matches))
+	for _, i := range matches {
+		hash, _ := m.Dependencies[i].Hash()
+		descriptions = append(descriptions, fmt.Sprintf("%s (%s)", m.Dependencies[i].Name, hash))
+	}
+	return -1, fmt.Errorf("%q matches %d dependencies, use the full hash to select one of: %s", ref, len(matches), strings.Join(descriptions, ", "))
+}
+
+// Remove removes the dependency with the name or hash
+func (m *Manifest) Remove(ref string) (*Spec, error) {
+	i, err := m.Find(ref)
+	if err != nil {
+		return nil, err
+	}
+	removed := m.Dependencies[i]
+	m.Dependencies = append(m.Dependencies[:i], m.Dependencies[i+1:]...)
+	return &removed, nil
+}
+
+// Update is a change to the version of a dependency
+type Update struct {
+	Name string
+	Type string
+	From string
+	To   string
+}
+
+// Update sets the version of the selected dependencies to their latest version
+// selected is called for each dependency with a different latest version, including the snoozed and ignored ones,
+// use Spec.Outdated to skip them unless the dependency was explicitly selected
+// Returns the updates in the same order as the manifest
+func (m *Manifest) Update(selected func(dep Spec) bool) []Update {
+	updates := make([]Update, 0)
+	for i := range m.Dependencies {
+		dep := &m.Dependencies[i]
+		if !dep.hasUpdate() || !selected(*dep) {
+			continue
+		}
+		updates = append(updates, Update{Name: dep.Name, Type: dep.Type, From: dep.Version, To: dep.LatestVersion})
+		dep.Version = dep.LatestVersion
+		dep.UpdateKind = ""
+	}
+	return updates
+}
+
+const (
+	// DefaultConcurrency is the number of dependencies resolved at the same time
+	DefaultConcurrency = 4
+	// DefaultHostConcurrency is the number of dependencies resolved at the same time from a single host
+	DefaultHostConcurrency = 2
+	// DefaultTimeout is how long to wait for the latest version of a single dependency
+	DefaultTimeout = time.Minute
+)
+
+// LatestOptions control how the latest versions are retrieved
+// Zero values use the defaults
+type LatestOptions struct {
+	Concurrency     int
+	HostConcurrency int
+	// Timeout applies to each dependency, it can be overridden by the dependency's own timeout
+	Timeout time.Duration
+	// MinAge skips versions published more recently, it can be overridden by the dependency's own minAge
+	MinAge time.Duration
+}
+
+// Latest retrieves the latest version of each dependency with the fetcher registered for its type
+// Dependencies are resolved concurrently, the order of the returned dependencies is the same as the manifest
+// An error is returned when the context is canceled before all of the dependencies are resolved
+func (m *Manifest) Latest(ctx context.Context, fetchers *fetcher.Registry, opts LatestOptions) (*Manifest, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	hostConcurrency := opts.HostConcurrency
+	if hostConcurrency <= 0 {
+		hostConcurrency = DefaultHostConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	// limit the total number of workers and the number of workers per host
+	workers := make(chan struct{}, concurrency)
+	hosts := make(map[string]chan struct{})
+	hostLimits := make([]chan struct{}, len(m.Dependencies))
+	for i, dep := range m.Dependencies {
+		host := dependencyHost(fetchers, dep)
+		if _, ok := hosts[host]; !ok {
+			hosts[host] = make(chan struct{}, hostConcurrency)
+		}
+		hostLimits[i] = hosts[host]
+	}
+
+	dependencies := make([]Spec, len(m.Dependencies))
+	var wg sync.WaitGroup
+	for i := range m.Dependencies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// wait for the host first so that workers are not held by dependencies waiting on a busy host
+			select {
+			case hostLimits[i] <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-hostLimits[i] }()
+			select {
+			case workers <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-workers }()
+
+			dependencies[i] = resolve(ctx, fetchers, m.Dependencies[i], opts)
+		}(i)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("could not retrieve latest versions: %v", err)
+	}
+
 	updatedManifest := &Manifest{APIVersion: m.APIVersion}
-	dc := docker.New()
-	gc := github.New()
-	for _, dep := range m.Dependencies {
-		depType := dep.GetType()
-		switch depType {
-		case DockerType:
-			latest, err := dc.LatestVersion(dep.Name, dep.Mask)
-			if err != nil {
-				if err == fetcher.ErrEmptyVerionsList {
-					dep.Notes = fmt.Sprintf("could not find latest tag")
-				} else {
-					dep.Notes = fmt.Sprintf("error retrieving latest tag: %v", err)
-				}
+	if len(dependencies) > 0 {
+		updatedManifest.Dependencies = dependencies
+	}
+	return updatedManifest, nil
+}
+
+// resolve sets the type of the dependency and retrieves its latest version
+func resolve(ctx context.Context, fetchers *fetcher.Registry, dep Spec, opts LatestOptions) Spec {
+	timeout, minAge := opts.Timeout, opts.MinAge
+	depType := dep.TypeFrom(fetchers)
+	dep.Type = depType
+	switch depType {
+	case ManualType:
+		// manual dependencies are never looked up, the notes of a previous type would fail every check
+		dep.Notes = ""
+		dep.LatestNote = ""
+	case UnknownType:
+		dep.Notes = fmt.Sprintf("could not determine type")
+	default:
+		f, ok := fetchers.Get(depType)
+		if !ok {
+			dep.Notes = fmt.Sprintf("unhandled type %q", depType)
+			break
+		}
+		if dep.Timeout != "" {
+			depTimeout, err := time.ParseDuration(dep.Timeout)
+			if err != nil || depTimeout <= 0 {
+				dep.Notes = fmt.Sprintf("invalid timeout %q", dep.Timeout)
+				break
 			}
-			dep.LatestVersion = latest.String()
-			dep.Notes = ""
-		case GithubType:
-			latest, err := gc.LatestVersion(dep.Name, dep.Mask)
+			timeout = depTimeout
+		}
+		if dep.MinAge != "" {
+			depMinAge, err := ParseAge(dep.MinAge)
 			if err != nil {
-				if err == fetcher.ErrEmptyVerionsList {
-					dep.Notes = fmt.Sprintf("could not find latest tag")
-				} else {
-					dep.Notes = fmt.Sprintf("error retrieving latest tag: %v", err)
-				}
+				dep.Notes = fmt.Sprintf("invalid minAge %q", dep.MinAge)
+				break
 			}
-			dep.LatestVersion = latest.String()
-			dep.Notes = ""
-		case ManualType:
-		case UnknownType:
-			dep.Notes = fmt.Sprintf("could not determine type")
-		default:
-			dep.Notes = fmt.Sprintf("unhandled type %q", depType)
+			minAge = depMinAge
 		}
-		dep.Type = depType
-		updatedManifest.Dependencies = append(updatedManifest.Dependencies, dep)
+		dep = latest(ctx, f, dep, timeout, minAge)
 	}
+	dep.UpdateKind = dep.ClassifyUpdate()
+	return dep
+}
 
-	return updatedManifest, nil
+// dependencyHost returns the host the dependency is retrieved from, defaulting to its type
+func dependencyHost(fetchers *fetcher.Registry, dep Spec) string {
+	depType := dep.TypeFrom(fetchers)
+	f, ok := fetchers.Get(depType)
+	if !ok {
+		return depType
+	}
+	if resolver, ok := f.(fetcher.HostResolver); ok {
+		return resolver.Host(dep.Name)
+	}
+	return depType
+}
+
+// latest sets the latest version of the dependency, or a note when it could not be retrieved
+func latest(ctx context.Context, f fetcher.Fetcher, dep Spec, timeout, minAge time.Duration) Spec {
+	dep.Notes = ""
+	dep.LatestPrerelease = false
+	dep.LatestNote = ""
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	versions, err := f.AllVersions(ctx, dep.Name, dep.FetcherOptions())
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			dep.Notes = fmt.Sprintf("timed out retrieving latest tag after %s", timeout)
+		} else if err == fetcher.ErrEmptyVerionsList {
+			dep.Notes = fmt.Sprintf("could not find latest tag")
+		} else {
+			dep.Notes = fmt.Sprintf("error retrieving latest tag: %v", err)
+		}
+		return dep
+	}
+	latest := versions.Latest()
+	if latest == nil {
+		dep.Notes = fmt.Sprintf("could not find latest tag")
+		return dep
+	}
+	if minAge > 0 {
+		latest, err = oldEnough(ctx, f, dep, versions, minAge)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				dep.Notes = fmt.Sprintf("timed out retrieving latest tag after %s", timeout)
+			} else {
+				dep.Notes = fmt.Sprintf("error retrieving latest tag: %v", err)
+			}
+			return dep
+		}
+		if latest == nil {
+			dep.Notes = fmt.Sprintf("could not find a tag older than %s", minAge)
+			return dep
+		}
+	}
+	dep.LatestVersion = latest.String()
+	dep.LatestPrerelease = versions.IsPrerelease(*latest)
+	dep.LatestNote = versions.Metadata[*latest].Note
+	return dep
+}
+
+// oldEnough returns the newest version that was published at least minAge ago
+// The current version is always old enough, so that a younger current version is never downgraded
+func oldEnough(ctx context.Context, f fetcher.Fetcher, dep Spec, versions *versioned.Versions, minAge time.Duration) (*versioned.Versioned, error) {
+	sort.Sort(versions)
+	publisher, _ := f.(fetcher.Publisher)
+	for i := len(versions.List) - 1; i >= 0; i-- {
+		v := versions.List[i]
+		if v.String() == dep.Version {
+			return &v, nil
+		}
+		published := versions.Metadata[v].Published
+		if published.IsZero() {
+			if publisher == nil {
+				return nil, fmt.Errorf("minAge is not supported for the %q type", dep.Type)
+			}
+			var err error
+			if published, err = publisher.PublishedAt(ctx, dep.Name, v); err != nil {
+				return nil, err
+			}
+		}
+		if time.Since(published) >= minAge {
+			return &v, nil
+		}
+	}
+	return nil, nil
 }
 
 func (m *Manifest) ToMap() (string, map[string]Spec, error) {
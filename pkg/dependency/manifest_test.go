@@ -0,0 +1,504 @@
+package dependency
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+type fakeFetcher struct {
+	prefix   string
+	versions map[string][]string
+}
+
+func (f fakeFetcher) Detect(name string) bool {
+	return strings.HasPrefix(name, f.prefix)
+}
+
+func (f fakeFetcher) AllVersions(_ context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	tags, ok := f.versions[name]
+	if !ok {
+		return nil, fmt.Errorf("%q not found", name)
+	}
+	if len(tags) == 0 {
+		return nil, fetcher.ErrEmptyVerionsList
+	}
+	return fetcher.Filter(versioned.FromStringSlice(tags), opts)
+}
+
+func (f fakeFetcher) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := f.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return versions.Latest(), nil
+}
+
+func TestLatest(t *testing.T) {
+	fetchers := fetcher.NewRegistry()
+	fetchers.Register("artifact", fakeFetcher{
+		prefix: "artifacts.example.com/",
+		versions: map[string][]string{
+			"artifacts.example.com/foo":   {"1.0.0", "1.1.0", "1.2.0-rc.1"},
+			"artifacts.example.com/empty": {},
+		},
+	})
+	fetchers.RegisterFallback(DockerType, fakeFetcher{
+		versions: map[string][]string{
+			"busybox": {"1.28.1", "1.28.4", "1.29.0", "2.0.0"},
+		},
+	})
+
+	manifest := Manifest{
+		APIVersion: "v0.1",
+		Dependencies: []Spec{
+			{Name: "artifacts.example.com/foo", Version: "1.0.0"},
+			{Name: "artifacts.example.com/empty", Version: "1.0.0"},
+			{Name: "artifacts.example.com/missing", Version: "1.0.0"},
+			{Name: "busybox", Version: "1.28.1", Mask: "1.28.[0-9]+"},
+			{Name: "busybox", Version: "1.28.1", UpdatePolicy: "minor"},
+			{Name: "busybox", Version: "1.28.1", UpdatePolicy: "pinned"},
+			{Name: "something", Type: ManualType, Version: "1.0.0"},
+			// a dependency that failed a lookup before it was switched to manual
+			{Name: "switched", Type: ManualType, Version: "1.0.0", Notes: "could not find latest tag", LatestNote: "appVersion 1.0.0"},
+			{Name: "deb-package", Type: "deb", Version: "1.0.0"},
+		},
+	}
+	expected := []Spec{
+		{Name: "artifacts.example.com/foo", Type: "artifact", Version: "1.0.0", LatestVersion: "1.1.0", UpdateKind: "minor"},
+		{Name: "artifacts.example.com/empty", Type: "artifact", Version: "1.0.0", Notes: "could not find latest tag"},
+		{Name: "artifacts.example.com/missing", Type: "artifact", Version: "1.0.0", Notes: "error retrieving latest tag: \"artifacts.example.com/missing\" not found"},
+		{Name: "busybox", Type: DockerType, Version: "1.28.1", LatestVersion: "1.28.4", Mask: "1.28.[0-9]+", UpdateKind: "patch"},
+		{Name: "busybox", Type: DockerType, Version: "1.28.1", LatestVersion: "1.29.0", UpdatePolicy: "minor", UpdateKind: "minor"},
+		{Name: "busybox", Type: DockerType, Version: "1.28.1", LatestVersion: "1.28.1", UpdatePolicy: "pinned"},
+		{Name: "something", Type: ManualType, Version: "1.0.0"},
+		{Name: "switched", Type: ManualType, Version: "1.0.0"},
+		{Name: "deb-package", Type: "deb", Version: "1.0.0", Notes: "unhandled type \"deb\""},
+	}
+
+	updated, err := manifest.Latest(context.Background(), fetchers, LatestOptions{Concurrency: 3, HostConcurrency: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.APIVersion != manifest.APIVersion {
+		t.Errorf("expected API version %q, instead got %q", manifest.APIVersion, updated.APIVersion)
+	}
+	if !reflect.DeepEqual(updated.Dependencies, expected) {
+		t.Errorf("expected dependencies to be:\n%+v\ninstead got:\n%+v", expected, updated.Dependencies)
+	}
+}
+
+// slowFetcher records the max number of concurrent requests per host
+type slowFetcher struct {
+	mu      *sync.Mutex
+	current map[string]int
+	max     map[string]int
+}
+
+func (f slowFetcher) Host(name string) string {
+	return strings.Split(name, "/")[0]
+}
+
+func (f slowFetcher) AllVersions(_ context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	host := f.Host(name)
+	f.mu.Lock()
+	f.current[host]++
+	if f.current[host] > f.max[host] {
+		f.max[host] = f.current[host]
+	}
+	f.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	f.mu.Lock()
+	f.current[host]--
+	f.mu.Unlock()
+	return versioned.FromStringSlice([]string{name + "-1.0.0"}), nil
+}
+
+func (f slowFetcher) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := f.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return versions.Latest(), nil
+}
+
+func TestLatestConcurrency(t *testing.T) {
+	f := slowFetcher{mu: &sync.Mutex{}, current: map[string]int{}, max: map[string]int{}}
+	fetchers := fetcher.NewRegistry()
+	fetchers.RegisterFallback(DockerType, f)
+
+	manifest := Manifest{}
+	for i := 0; i < 20; i++ {
+		manifest.Dependencies = append(manifest.Dependencies, Spec{Name: fmt.Sprintf("host%d/image%d", i%2, i)})
+	}
+
+	updated, err := manifest.Latest(context.Background(), fetchers, LatestOptions{Concurrency: 8, HostConcurrency: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, dep := range updated.Dependencies {
+		if dep.Name != manifest.Dependencies[i].Name || dep.LatestVersion != dep.Name+"-1.0.0" {
+			t.Errorf("expected dependency %d to be %q, instead got %q with latest version %q", i, manifest.Dependencies[i].Name, dep.Name, dep.LatestVersion)
+		}
+	}
+	for host, max := range f.max {
+		if max > 3 {
+			t.Errorf("expected at most 3 concurrent requests to %q, instead got %d", host, max)
+		}
+	}
+}
+
+// blockingFetcher waits until the context is done
+type blockingFetcher struct{}
+
+func (f blockingFetcher) AllVersions(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f blockingFetcher) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	_, err := f.AllVersions(ctx, name, opts)
+	return nil, err
+}
+
+func TestLatestTimeout(t *testing.T) {
+	fetchers := fetcher.NewRegistry()
+	fetchers.RegisterFallback(DockerType, blockingFetcher{})
+
+	manifest := Manifest{
+		Dependencies: []Spec{
+			{Name: "busybox", Version: "1.28.1"},
+			{Name: "alpine", Version: "3.8", Timeout: "10ms"},
+			{Name: "nginx", Version: "1.19", Timeout: "soon"},
+		},
+	}
+	expectedNotes := []string{
+		"timed out retrieving latest tag after 20ms",
+		"timed out retrieving latest tag after 10ms",
+		"invalid timeout \"soon\"",
+	}
+
+	updated, err := manifest.Latest(context.Background(), fetchers, LatestOptions{Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, dep := range updated.Dependencies {
+		if dep.Notes != expectedNotes[i] {
+			t.Errorf("expected notes for %q to be %q, instead got %q", dep.Name, expectedNotes[i], dep.Notes)
+		}
+	}
+}
+
+func TestLatestCanceled(t *testing.T) {
+	fetchers := fetcher.NewRegistry()
+	fetchers.RegisterFallback(DockerType, blockingFetcher{})
+
+	manifest := Manifest{}
+	for i := 0; i < 10; i++ {
+		manifest.Dependencies = append(manifest.Dependencies, Spec{Name: fmt.Sprintf("image%d", i)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := manifest.Latest(ctx, fetchers, LatestOptions{Concurrency: 2, Timeout: time.Hour})
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("expected a context canceled error, instead got: %v", err)
+	}
+}
+
+func TestDetermineType(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{source: "busybox", expected: DockerType},
+		{source: "gcr.io/google-containers/kube-apiserver", expected: DockerType},
+		{source: "https://github.com/kubernetes/kubernetes", expected: GithubType},
+		{source: "github.com/kubernetes/kubernetes", expected: GithubType},
+		{source: "https://www.npmjs.com/package/@types/node", expected: NpmType},
+		{source: "https://pypi.org/project/requests/", expected: PyPIType},
+		{source: "https://crates.io/crates/serde", expected: CratesType},
+		{source: "oci://registry-1.docker.io/bitnamicharts/nginx", expected: HelmType},
+		{source: "registry.terraform.io/hashicorp/aws", expected: TerraformType},
+		{source: "https://gitlab.com/gitlab-org/gitlab-runner", expected: GitLabType},
+		{source: "codeberg.org/forgejo/forgejo", expected: GiteaType},
+		{source: "https://bitbucket.org/atlassian/python-bitbucket", expected: BitbucketType},
+		{source: "https://git.kernel.org/pub/scm/git/git.git", expected: GitType},
+		{source: "https://gitlab.com/gitlab-org/gitlab-runner.git", expected: GitLabType},
+		{source: ManualType, expected: ManualType},
+	}
+	for _, test := range tests {
+		if depType := DetermineType(DefaultFetchers(), test.source); depType != test.expected {
+			t.Errorf("expected type of %q to be %q, instead got %q", test.source, test.expected, depType)
+		}
+	}
+
+	if depType := DetermineType(fetcher.NewRegistry(), "busybox"); depType != UnknownType {
+		t.Errorf("expected type to be %q without any fetchers, instead got %q", UnknownType, depType)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	manifest := Manifest{
+		APIVersion: "v0.1",
+		Dependencies: []Spec{
+			{Name: "a", Type: DockerType, Version: "1.0", LatestVersion: "1.1"},
+			{Name: "b", Type: GithubType, Version: "2.0", LatestVersion: "2.0"},
+			{Name: "c", Type: GithubType, Version: "3.0", LatestVersion: "3.2"},
+			{Name: "d", Type: DockerType, Version: "4.0"},
+			{Name: "e", Type: DockerType, Version: "5.0", LatestVersion: "5.1"},
+			{Name: "f", Type: DockerType, Version: "6.0", LatestVersion: "6.1", SnoozeUntil: "2999-01-01"},
+		},
+	}
+	var called []string
+	updates := manifest.Update(func(dep Spec) bool {
+		called = append(called, dep.Name)
+		return dep.Name != "e"
+	})
+
+	// snoozed dependencies are selected too, only the caller knows if it was named explicitly
+	expectedUpdates := []Update{
+		{Name: "a", Type: DockerType, From: "1.0", To: "1.1"},
+		{Name: "c", Type: GithubType, From: "3.0", To: "3.2"},
+		{Name: "f", Type: DockerType, From: "6.0", To: "6.1"},
+	}
+	if !reflect.DeepEqual(updates, expectedUpdates) {
+		t.Errorf("expected updates %v, instead got %v", expectedUpdates, updates)
+	}
+	// only dependencies with a different latest version are selected
+	if expected := []string{"a", "c", "e", "f"}; !reflect.DeepEqual(called, expected) {
+		t.Errorf("expected %v to be selected, instead got %v", expected, called)
+	}
+	expectedVersions := []string{"1.1", "2.0", "3.2", "4.0", "5.0", "6.1"}
+	for i, dep := range manifest.Dependencies {
+		if dep.Version != expectedVersions[i] {
+			t.Errorf("expected %q to have version %q, instead got %q", dep.Name, expectedVersions[i], dep.Version)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	manifest := Manifest{
+		Dependencies: []Spec{
+			{Name: "busybox", Type: DockerType},
+			{Name: "alpine", Type: DockerType},
+			{Name: "alpine", Type: DockerType, Mask: "3.[0-9]+"},
+		},
+	}
+	busyboxHash, _ := manifest.Dependencies[0].Hash()
+	alpineHash, _ := manifest.Dependencies[2].Hash()
+
+	tests := []struct {
+		ref         string
+		expected    int
+		expectedErr string
+	}{
+		{ref: "busybox", expected: 0},
+		{ref: busyboxHash, expected: 0},
+		{ref: busyboxHash[:7], expected: 0},
+		{ref: alpineHash, expected: 2},
+		{ref: "alpine", expectedErr: `"alpine" matches 2 dependencies`},
+		{ref: "nginx", expectedErr: `"nginx" is not in the config file`},
+		{ref: "", expectedErr: "a name or hash is required"},
+	}
+	for _, test := range tests {
+		i, err := manifest.Find(test.ref)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("%q: expected an error to contain %q, instead got: %v", test.ref, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.ref, err)
+			continue
+		}
+		if i != test.expected {
+			t.Errorf("%q: expected to find dependency %d, instead got %d", test.ref, test.expected, i)
+		}
+	}
+
+	removed, err := manifest.Remove(alpineHash)
+	if err != nil {
+		t.Fatalf("unexpected error removing %q: %v", alpineHash, err)
+	}
+	if removed.Mask != "3.[0-9]+" || len(manifest.Dependencies) != 2 {
+		t.Errorf("expected the dependency with the mask to be removed, instead removed %v and left %v", removed, manifest.Dependencies)
+	}
+	if _, err := manifest.Find("alpine"); err != nil {
+		t.Errorf("expected %q to no longer be ambiguous, instead got: %v", "alpine", err)
+	}
+}
+
+// publisherFetcher returns versions published a day apart, the newest one an hour ago
+type publisherFetcher struct {
+	versions []string
+	// versions without a publication time in their metadata
+	lazy map[string]bool
+	// notes are set in the metadata of the versions
+	notes map[string]string
+}
+
+func (f publisherFetcher) published(version string) time.Time {
+	for i, v := range f.versions {
+		if v == version {
+			return time.Now().Add(-time.Hour - time.Duration(len(f.versions)-1-i)*24*time.Hour)
+		}
+	}
+	return time.Time{}
+}
+
+func (f publisherFetcher) AllVersions(_ context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	versions := versioned.FromStringSlice(f.versions)
+	for _, v := range f.versions {
+		if !f.lazy[v] {
+			versions.SetMetadata(versioned.Versioned(v), versioned.Metadata{Published: f.published(v), Note: f.notes[v]})
+		}
+	}
+	return fetcher.Filter(versions, opts)
+}
+
+func (f publisherFetcher) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := f.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return versions.Latest(), nil
+}
+
+func (f publisherFetcher) PublishedAt(_ context.Context, name string, version versioned.Versioned) (time.Time, error) {
+	return f.published(version.String()), nil
+}
+
+func TestLatestMinAge(t *testing.T) {
+	fetchers := fetcher.NewRegistry()
+	fetchers.Register("artifact", publisherFetcher{
+		versions: []string{"1.0.0", "1.1.0", "1.2.0", "1.3.0"},
+		lazy:     map[string]bool{"1.2.0": true, "1.3.0": true},
+	})
+	fetchers.RegisterFallback(DockerType, fakeFetcher{
+		versions: map[string][]string{"busybox": {"1.28.1", "1.28.4"}},
+	})
+
+	manifest := Manifest{
+		Dependencies: []Spec{
+			{Name: "foo", Type: "artifact", Version: "1.0.0"},
+			{Name: "foo", Type: "artifact", Version: "1.0.0", MinAge: "0"},
+			{Name: "foo", Type: "artifact", Version: "1.0.0", MinAge: "2d12h"},
+			{Name: "foo", Type: "artifact", Version: "1.0.0", MinAge: "30d"},
+			{Name: "foo", Type: "artifact", Version: "1.3.0", MinAge: "30d"},
+			{Name: "foo", Type: "artifact", Version: "1.0.0", MinAge: "a week"},
+			{Name: "busybox", Version: "1.28.1"},
+		},
+	}
+	expected := []Spec{
+		{Name: "foo", Type: "artifact", Version: "1.0.0", LatestVersion: "1.2.0", UpdateKind: "minor"},
+		{Name: "foo", Type: "artifact", Version: "1.0.0", LatestVersion: "1.3.0", MinAge: "0", UpdateKind: "minor"},
+		{Name: "foo", Type: "artifact", Version: "1.0.0", LatestVersion: "1.0.0", MinAge: "2d12h"},
+		{Name: "foo", Type: "artifact", Version: "1.0.0", LatestVersion: "1.0.0", MinAge: "30d"},
+		{Name: "foo", Type: "artifact", Version: "1.3.0", LatestVersion: "1.3.0", MinAge: "30d"},
+		{Name: "foo", Type: "artifact", Version: "1.0.0", MinAge: "a week", Notes: "invalid minAge \"a week\""},
+		{Name: "busybox", Type: DockerType, Version: "1.28.1", Notes: "error retrieving latest tag: minAge is not supported for the \"docker\" type"},
+	}
+
+	updated, err := manifest.Latest(context.Background(), fetchers, LatestOptions{MinAge: 12 * time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(updated.Dependencies, expected) {
+		t.Errorf("expected dependencies to be:\n%+v\ninstead got:\n%+v", expected, updated.Dependencies)
+	}
+}
+
+func TestLatestNote(t *testing.T) {
+	fetchers := fetcher.NewRegistry()
+	fetchers.Register("chart", publisherFetcher{
+		versions: []string{"6.2.0", "7.0.0"},
+		notes:    map[string]string{"6.2.0": "appVersion 1.19.2", "7.0.0": "appVersion 1.19.3"},
+	})
+
+	manifest := Manifest{
+		Dependencies: []Spec{
+			{Name: "nginx", Type: "chart", Version: "6.2.0", LatestNote: "appVersion 1.19.2"},
+			{Name: "nginx", Type: "chart", Version: "6.2.0", Constraint: "<7", LatestNote: "appVersion 1.19.3"},
+		},
+	}
+	expected := []Spec{
+		{Name: "nginx", Type: "chart", Version: "6.2.0", LatestVersion: "7.0.0", LatestNote: "appVersion 1.19.3", UpdateKind: "major"},
+		{Name: "nginx", Type: "chart", Version: "6.2.0", Constraint: "<7", LatestVersion: "6.2.0", LatestNote: "appVersion 1.19.2"},
+	}
+
+	updated, err := manifest.Latest(context.Background(), fetchers, LatestOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(updated.Dependencies, expected) {
+		t.Errorf("expected dependencies to be:\n%+v\ninstead got:\n%+v", expected, updated.Dependencies)
+	}
+	for _, dep := range updated.Dependencies {
+		if dep.LookupFailed() {
+			t.Errorf("a note about the latest version is not a failed lookup: %+v", dep)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected time.Duration
+		err      bool
+	}{
+		{in: "7d", expected: 7 * 24 * time.Hour},
+		{in: "1d12h", expected: 36 * time.Hour},
+		{in: "36h", expected: 36 * time.Hour},
+		{in: "0", expected: 0},
+		{in: "-1d", err: true},
+		{in: "-2h", err: true},
+		{in: "a week", err: true},
+	}
+	for _, test := range tests {
+		age, err := ParseAge(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, instead got %s", test.in, age)
+			}
+			continue
+		}
+		if err != nil || age != test.expected {
+			t.Errorf("%q: expected %s, instead got %s and error %v", test.in, test.expected, age, err)
+		}
+	}
+}
+
+func TestSnoozed(t *testing.T) {
+	at := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		until    string
+		expected bool
+	}{
+		{until: "", expected: false},
+		{until: "2020-10-02", expected: true},
+		{until: "2020-10-01", expected: false},
+		{until: "2020-10-01T13:00:00Z", expected: true},
+		{until: "2020-10-01T14:00:00+02:00", expected: false},
+		{until: "next week", expected: false},
+	}
+	for _, test := range tests {
+		spec := Spec{Version: "1.0", LatestVersion: "1.1", SnoozeUntil: test.until}
+		if snoozed := spec.Snoozed(at); snoozed != test.expected {
+			t.Errorf("%q: expected snoozed to be %t, instead got %t", test.until, test.expected, snoozed)
+		}
+	}
+}
@@ -7,32 +7,49 @@ import (
 	"text/tabwriter"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
 )
 
 type ManifestWriter struct {
 	Writer        io.Writer
 	FilterOptions FilterOptions
+	// Fetchers determine the types that are not set, the built-in fetchers are used when nil
+	Fetchers *fetcher.Registry
 }
 
 type FilterOptions struct {
 	Outdated bool
 	Types    []string
+	// UpdateKinds selects outdated dependencies by the kind of update, ie. "patch"
+	UpdateKinds []string
 }
 
 func (mf ManifestWriter) WriteTable(m Manifest) {
 	fmt.Fprintf(mf.Writer, "String: %q\n", m.APIVersion)
 	tw := tabwriter.NewWriter(mf.Writer, 0, 0, 5, ' ', 0)
 	fmt.Fprintln(mf.Writer)
-	fmt.Fprintln(tw, "Name\tCurrent String\tLatest String\tType\tMask\tNotes")
-	fmt.Fprintln(tw, "------\t------\t------\t------\t------\t------")
-	for _, dep := range filteredDependencies(m.Dependencies, mf.FilterOptions) {
-		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", dep.Name, dep.Version, dep.LatestVersion, dep.GetType(), dep.Mask, dep.Notes)
+	fmt.Fprintln(tw, "Name\tCurrent String\tLatest String\tUpdate\tType\tMask\tNotes")
+	fmt.Fprintln(tw, "------\t------\t------\t------\t------\t------\t------")
+	fetchers := mf.Fetchers
+	if fetchers == nil {
+		fetchers = builtinFetchers()
+	}
+	for _, dep := range classified(filteredDependencies(m.Dependencies, mf.FilterOptions)) {
+		latestVersion := dep.LatestVersion
+		if dep.LatestPrerelease {
+			latestVersion = fmt.Sprintf("%s (pre-release)", latestVersion)
+		}
+		if dep.LatestNote != "" {
+			latestVersion = fmt.Sprintf("%s (%s)", latestVersion, dep.LatestNote)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", dep.Name, dep.Version, latestVersion, dep.UpdateKind, dep.TypeFrom(fetchers), dep.Mask, dep.Notes)
 	}
 	tw.Flush()
 }
 
 func (mf ManifestWriter) WriteYAML(m Manifest) error {
-	m.Dependencies = filteredDependencies(m.Dependencies, mf.FilterOptions)
+	m.Dependencies = classified(filteredDependencies(m.Dependencies, mf.FilterOptions))
 	b, err := yaml.Marshal(m)
 	if err != nil {
 		return fmt.Errorf("could not marshal to YAML: %v", err)
@@ -45,7 +62,7 @@ func (mf ManifestWriter) WriteYAML(m Manifest) error {
 }
 
 func (mf ManifestWriter) WriteJSON(m Manifest) error {
-	m.Dependencies = filteredDependencies(m.Dependencies, mf.FilterOptions)
+	m.Dependencies = classified(filteredDependencies(m.Dependencies, mf.FilterOptions))
 	b, err := json.MarshalIndent(m, "", "    ")
 	if err != nil {
 		return fmt.Errorf("could not marshal to JSON: %v", err)
@@ -57,6 +74,11 @@ func (mf ManifestWriter) WriteJSON(m Manifest) error {
 	return nil
 }
 
+// Filter returns the dependencies that match the options
+func (f FilterOptions) Filter(deps []Spec) []Spec {
+	return filteredDependencies(deps, f)
+}
+
 func filteredDependencies(deps []Spec, filter FilterOptions) []Spec {
 	filteredDependencies := make([]Spec, 0)
 	for _, dep := range deps {
@@ -65,10 +87,22 @@ func filteredDependencies(deps []Spec, filter FilterOptions) []Spec {
 			continue
 		}
 		// skip if requesting only outdate versions
-		if filter.Outdated && (dep.Version == dep.LatestVersion) {
+		if filter.Outdated && !dep.Outdated() {
+			continue
+		}
+		// skip if requesting specific kind(s) of updates, ignored and snoozed updates are never selected
+		if len(filter.UpdateKinds) > 0 && (!dep.Outdated() || !contains(filter.UpdateKinds, dep.ClassifyUpdate())) {
 			continue
 		}
 		filteredDependencies = append(filteredDependencies, dep)
 	}
 	return filteredDependencies
 }
+
+// classified sets the kind of update of each dependency, the config file may have been written without them
+func classified(deps []Spec) []Spec {
+	for i := range deps {
+		deps[i].UpdateKind = deps[i].ClassifyUpdate()
+	}
+	return deps
+}
@@ -3,7 +3,10 @@ package dependency
 import (
 	"bytes"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
 )
 
 func TestFilteredDependencies(t *testing.T) {
@@ -276,6 +279,116 @@ func TestFilteredDependencies(t *testing.T) {
 			expected: []Spec{},
 			filter:   FilterOptions{Outdated: true, Types: []string{"deb"}},
 		},
+		{
+			name: "filter update kinds",
+			deps: []Spec{
+				{
+					Name:          "foo",
+					Type:          "docker",
+					Version:       "v1.0.0",
+					LatestVersion: "v1.0.1",
+				},
+				{
+					Name:          "bar",
+					Type:          "github",
+					Version:       "v27.0",
+					LatestVersion: "v28.0",
+				},
+				{
+					Name:          "barfoo",
+					Type:          "deb",
+					Version:       "1.1",
+					LatestVersion: "1.1",
+				},
+				{
+					Name:          "foobar",
+					Type:          "docker",
+					Version:       "latest",
+					LatestVersion: "stable",
+				},
+			},
+			expected: []Spec{
+				{
+					Name:          "foo",
+					Type:          "docker",
+					Version:       "v1.0.0",
+					LatestVersion: "v1.0.1",
+				},
+			},
+			filter: FilterOptions{UpdateKinds: []string{"patch"}},
+		},
+		{
+			name: "filter update kinds with ignored and snoozed versions",
+			deps: []Spec{
+				{
+					Name:          "foo",
+					Type:          "docker",
+					Version:       "v1.0.0",
+					LatestVersion: "v1.0.1",
+					Ignore:        []string{"v1.0.1"},
+				},
+				{
+					Name:          "bar",
+					Type:          "github",
+					Version:       "v27.0.0",
+					LatestVersion: "v27.0.1",
+					SnoozeUntil:   "2999-01-01",
+				},
+				{
+					Name:          "barfoo",
+					Type:          "deb",
+					Version:       "1.1.0",
+					LatestVersion: "1.1.1",
+				},
+			},
+			expected: []Spec{
+				{
+					Name:          "barfoo",
+					Type:          "deb",
+					Version:       "1.1.0",
+					LatestVersion: "1.1.1",
+				},
+			},
+			filter: FilterOptions{UpdateKinds: []string{"patch"}},
+		},
+		{
+			name: "filter outdated with ignored and snoozed versions",
+			deps: []Spec{
+				{
+					Name:          "foo",
+					Type:          "docker",
+					Version:       "v1.0.0",
+					LatestVersion: "v1.1.0",
+					Ignore:        []string{"v1.1.*"},
+				},
+				{
+					Name:          "bar",
+					Type:          "github",
+					Version:       "v27.0",
+					LatestVersion: "v27.1",
+					SnoozeUntil:   "2999-01-01",
+				},
+				{
+					Name:          "barfoo",
+					Type:          "deb",
+					Version:       "1.1",
+					LatestVersion: "1.2",
+					Ignore:        []string{"1.3"},
+					SnoozeUntil:   "2000-01-01T00:00:00Z",
+				},
+			},
+			expected: []Spec{
+				{
+					Name:          "barfoo",
+					Type:          "deb",
+					Version:       "1.1",
+					LatestVersion: "1.2",
+					Ignore:        []string{"1.3"},
+					SnoozeUntil:   "2000-01-01T00:00:00Z",
+				},
+			},
+			filter: FilterOptions{Outdated: true},
+		},
 	}
 
 	for _, test := range filteredTests {
@@ -350,15 +463,15 @@ var manifest = Manifest{
 
 var tableText = `String: "v1.0"
 
-Name                                         Current String     Latest String     Type       Mask             Notes
-------                                       ------             ------            ------     ------           ------
-alpine                                       3.6                3.8               docker                                                    
-google/cadvisor                              v0.28.1            v0.30.2           docker                                                    
-google/cadvisor                              v0.29.2            v0.29.2           docker     v0.29.[0-9]+                                   
-cadvisor                                     v0.28.1                              docker     v0.29.[0-9]+     could not find latest tag     
-gcr.io/google-containers/kube-apiserver      v1.9.6             v1.9.9            docker     v1.9.[0-9]+                                    
-quay.io/coreos/etcd                          v3.1.13            v3.1.18           docker     v3.1.[0-9]+                                    
-https://github.com/kubernetes/kubernetes     v1.9.6                               github     v1.9.[0-9]+      unhandled type "github"       
+Name                                         Current String     Latest String     Update     Type       Mask             Notes
+------                                       ------             ------            ------     ------     ------           ------
+alpine                                       3.6                3.8               minor      docker                                                    
+google/cadvisor                              v0.28.1            v0.30.2           minor      docker                                                    
+google/cadvisor                              v0.29.2            v0.29.2                      docker     v0.29.[0-9]+                                   
+cadvisor                                     v0.28.1                                         docker     v0.29.[0-9]+     could not find latest tag     
+gcr.io/google-containers/kube-apiserver      v1.9.6             v1.9.9            patch      docker     v1.9.[0-9]+                                    
+quay.io/coreos/etcd                          v3.1.13            v3.1.18           patch      docker     v3.1.[0-9]+                                    
+https://github.com/kubernetes/kubernetes     v1.9.6                                          github     v1.9.[0-9]+      unhandled type "github"       
 `
 
 func TestWriteTable(t *testing.T) {
@@ -372,16 +485,29 @@ func TestWriteTable(t *testing.T) {
 	}
 }
 
+func TestWriteTableFetchers(t *testing.T) {
+	fetchers := fetcher.NewRegistry()
+	fetchers.Register("artifact", fakeFetcher{prefix: "artifacts.example.com/"})
+	var b bytes.Buffer
+	mw := ManifestWriter{Writer: &b, Fetchers: fetchers}
+	mw.WriteTable(Manifest{Dependencies: []Spec{{Name: "artifacts.example.com/foo", Version: "1.0.0"}}})
+	if !strings.Contains(b.String(), "artifact") {
+		t.Errorf("expected the type to be determined with the fetchers, instead got\n%s", b.String())
+	}
+}
+
 var yamlText = `apiversion: v1.0
 dependencies:
   - name: alpine
     type: docker
     version: "3.6"
     latestVersion: "3.8"
+    updateKind: minor
   - name: google/cadvisor
     type: docker
     version: v0.28.1
     latestVersion: v0.30.2
+    updateKind: minor
   - name: google/cadvisor
     type: docker
     version: v0.29.2
@@ -397,11 +523,13 @@ dependencies:
     version: v1.9.6
     latestVersion: v1.9.9
     mask: v1.9.[0-9]+
+    updateKind: patch
   - name: quay.io/coreos/etcd
     type: docker
     version: v3.1.13
     latestVersion: v3.1.18
     mask: v3.1.[0-9]+
+    updateKind: patch
   - name: https://github.com/kubernetes/kubernetes
     type: github
     version: v1.9.6
@@ -429,6 +557,7 @@ var jsonText = `{
             "version": "3.6",
             "latestVersion": "3.8",
             "mask": "",
+            "updateKind": "minor",
             "notes": ""
         },
         {
@@ -437,6 +566,7 @@ var jsonText = `{
             "version": "v0.28.1",
             "latestVersion": "v0.30.2",
             "mask": "",
+            "updateKind": "minor",
             "notes": ""
         },
         {
@@ -461,6 +591,7 @@ var jsonText = `{
             "version": "v1.9.6",
             "latestVersion": "v1.9.9",
             "mask": "v1.9.[0-9]+",
+            "updateKind": "patch",
             "notes": ""
         },
         {
@@ -469,6 +600,7 @@ var jsonText = `{
             "version": "v3.1.13",
             "latestVersion": "v3.1.18",
             "mask": "v3.1.[0-9]+",
+            "updateKind": "patch",
             "notes": ""
         },
         {
@@ -4,29 +4,62 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
 )
 
 const (
-	UnknownType = "unknown"
-	ManualType  = "manual"
-	DockerType  = "docker"
-	GithubType  = "github"
-
-	githubTypePrefix      = "https://github.com/"
-	githubTypePrefixShort = "github.com/"
+	UnknownType   = "unknown"
+	ManualType    = "manual"
+	DockerType    = "docker"
+	GithubType    = "github"
+	GitLabType    = "gitlab"
+	GiteaType     = "gitea"
+	BitbucketType = "bitbucket"
+	GitType       = "git"
+	GoModType     = "gomod"
+	NpmType       = "npm"
+	PyPIType      = "pypi"
+	CratesType    = "crates"
+	HelmType      = "helm"
+	TerraformType = "terraform"
 )
 
 // Spec describes a resource
-// Type: github, docker, manual
-// Source will be specific to a 'Type'
+// Type: github, gitlab, gitea, bitbucket, git, docker, gomod, npm, pypi, crates, helm, terraform, manual
+// Source will be specific to a 'Type', ie. "releases", "tags" or "both" for github, gitlab and gitea
 type Spec struct {
 	Name          string `yaml:"name" json:"name"`
 	Type          string `yaml:"type" json:"type"`
 	Version       string `yaml:"version" json:"version"`
 	LatestVersion string `yaml:"latestVersion,omitempty" json:"latestVersion"`
 	Mask          string `yaml:"mask,omitempty" json:"mask"`
-	Notes         string `yaml:"notes,omitempty" json:"notes"`
+	Constraint    string `yaml:"constraint,omitempty" json:"constraint,omitempty"`
+	Source        string `yaml:"source,omitempty" json:"source,omitempty"`
+	Prerelease    string `yaml:"prerelease,omitempty" json:"prerelease,omitempty"`
+	// UpdatePolicy limits updates relative to Version, ie. "patch", "minor", "major" or "pinned"
+	UpdatePolicy string `yaml:"updatePolicy,omitempty" json:"updatePolicy,omitempty"`
+	// Timeout overrides how long to wait for the latest version, ie. "30s"
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// MinAge skips versions published more recently, ie. "7d" or "36h", "0" disables it
+	MinAge string `yaml:"minAge,omitempty" json:"minAge,omitempty"`
+	// Ignore lists versions or regex patterns that are never proposed, ie. "1.17.4" or "1.18.*"
+	Ignore []string `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+	// SnoozeUntil hides available updates until the date, ie. "2020-10-01" or "2020-10-01T12:00:00Z"
+	SnoozeUntil string `yaml:"snoozeUntil,omitempty" json:"snoozeUntil,omitempty"`
+	// Locations are where the version is written in other files, 'gofer apply' writes the version to them
+	Locations []Location `yaml:"locations,omitempty" json:"locations,omitempty"`
+	// LatestPrerelease is true when LatestVersion is a pre-release
+	LatestPrerelease bool `yaml:"latestPrerelease,omitempty" json:"latestPrerelease,omitempty"`
+	// LatestNote is information about LatestVersion from its source, ie. the appVersion of a Helm chart, it is not an error
+	LatestNote string `yaml:"latestNote,omitempty" json:"latestNote,omitempty"`
+	// UpdateKind is "major", "minor" or "patch" when LatestVersion is newer, empty when the versions are not semantic
+	UpdateKind string `yaml:"updateKind,omitempty" json:"updateKind,omitempty"`
+	Notes      string `yaml:"notes,omitempty" json:"notes"`
 }
 
 func (s Spec) Hash() (string, error) {
@@ -35,28 +68,137 @@ func (s Spec) Hash() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("could not get hash: %v", err)
 	}
+	// only include when set to keep the hash of existing dependencies the same
+	if s.Constraint != "" {
+		if _, err := fmt.Fprintf(h, "%s", s.Constraint); err != nil {
+			return "", fmt.Errorf("could not get hash: %v", err)
+		}
+	}
+	if s.UpdatePolicy != "" {
+		if _, err := fmt.Fprintf(h, "%s", s.UpdatePolicy); err != nil {
+			return "", fmt.Errorf("could not get hash: %v", err)
+		}
+	}
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// Outdated returns true when a latest version was found and it is different from the current version
+// Ignored latest versions and snoozed dependencies are not outdated
+func (s Spec) Outdated() bool {
+	return s.hasUpdate() && !fetcher.Ignored(s.LatestVersion, s.Ignore) && !s.Snoozed(time.Now())
+}
+
+func (s Spec) hasUpdate() bool {
+	return s.LatestVersion != "" && s.Version != s.LatestVersion
+}
+
+// Snoozed returns true when updates are hidden at the time, an invalid date is never snoozed
+func (s Spec) Snoozed(at time.Time) bool {
+	if s.SnoozeUntil == "" {
+		return false
+	}
+	until, err := ParseSnoozeUntil(s.SnoozeUntil)
+	if err != nil {
+		return false
+	}
+	return at.Before(until)
+}
+
+// snoozeDateFormat is the short format of the 'snoozeUntil' date, updates are snoozed until the start of the day in UTC
+const snoozeDateFormat = "2006-01-02"
+
+// ParseSnoozeUntil parses a date, ie. "2020-10-01", or a time in the RFC3339 format
+func ParseSnoozeUntil(in string) (time.Time, error) {
+	if until, err := time.Parse(snoozeDateFormat, in); err == nil {
+		return until, nil
+	}
+	until, err := time.Parse(time.RFC3339, in)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use the %q or RFC3339 formats", in, snoozeDateFormat)
+	}
+	return until, nil
+}
+
+// ClassifyUpdate returns the kind of update from the version to the latest version, empty when they are the same
+func (s Spec) ClassifyUpdate() string {
+	if !s.hasUpdate() {
+		return ""
+	}
+	return versioned.UpdateKind(versioned.Versioned(s.Version), versioned.Versioned(s.LatestVersion))
+}
+
+// LookupFailed returns true when the latest version could not be retrieved, the reason is in the notes
+func (s Spec) LookupFailed() bool {
+	return s.Notes != ""
+}
+
+// FetcherOptions returns the settings used to retrieve versions for the spec
+func (s Spec) FetcherOptions() fetcher.Options {
+	return fetcher.Options{
+		Mask:         s.Mask,
+		Constraint:   s.Constraint,
+		Source:       s.Source,
+		Prerelease:   s.Prerelease,
+		Version:      s.Version,
+		UpdatePolicy: s.UpdatePolicy,
+		Ignore:       s.Ignore,
+	}
+}
+
+// GetType returns the type of the spec, when not set it is determined with the built-in fetchers
+// Use TypeFrom with the registry passed to Manifest.Latest to also detect custom types
 func (s Spec) GetType() string {
+	return s.TypeFrom(builtinFetchers())
+}
+
+// TypeFrom returns the type of the spec, when not set it is determined with the registered fetchers
+func (s Spec) TypeFrom(fetchers *fetcher.Registry) string {
 	if s.Type != "" {
 		return s.Type
 	}
-	return DetermineType(s.Name)
+	return DetermineType(fetchers, s.Name)
 }
 
-// DetermineType will try to determine the type for the spec
+// DetermineType will try to determine the type for the spec from the registered fetchers
 // 'unknown' will be returned if it cannot be determined
-func DetermineType(source string) string {
+func DetermineType(fetchers *fetcher.Registry, source string) string {
 	// just return on custom type
 	if source == ManualType {
 		return ManualType
 	}
-	specType := UnknownType
-	if strings.HasPrefix(source, githubTypePrefix) || strings.HasPrefix(source, githubTypePrefixShort) {
-		specType = GithubType
-	} else {
-		specType = DockerType
+	if specType, ok := fetchers.Detect(source); ok {
+		return specType
+	}
+	return UnknownType
+}
+
+// ValidTypes returns the registered types and the 'manual' type
+func ValidTypes(fetchers *fetcher.Registry) []string {
+	return append(fetchers.Types(), ManualType)
+}
+
+// ParseAge parses a duration that can also be in days, ie. "7d", "36h" or "1d12h"
+func ParseAge(in string) (time.Duration, error) {
+	var days int64
+	rest := in
+	if i := strings.Index(in, "d"); i != -1 {
+		n, err := strconv.ParseInt(in[:i], 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", in)
+		}
+		days, rest = n, in[i+1:]
+	}
+	var age time.Duration
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", in)
+		}
+		age = d
+	}
+	age += time.Duration(days) * 24 * time.Hour
+	if age < 0 {
+		return 0, fmt.Errorf("invalid age %q", in)
 	}
-	return specType
+	return age, nil
 }
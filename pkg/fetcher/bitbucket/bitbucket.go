@@ -0,0 +1,169 @@
+package bitbucket
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/fetcher/forge"
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const (
+	// hostsEnv lists Bitbucket Server or Data Center instances, ie. 'bitbucket.example.com'
+	hostsEnv = "GOFER_BITBUCKET_HOSTS"
+
+	// publicHost is Bitbucket Cloud, its API is served from a different host
+	publicHost      = "bitbucket.org"
+	defaultCloudAPI = "https://api.bitbucket.org/2.0"
+
+	// max page size allowed by both APIs
+	perPage = 100
+)
+
+type Client struct {
+	client    *http.Client
+	instances map[string]forge.Instance
+	cloudAPI  string
+}
+
+// New returns a dependency fetcher for the tags of Bitbucket repositories
+// The name is the URL of the repository, ie. 'https://bitbucket.org/workspace/repo',
+// or 'https://bitbucket.example.com/projects/KEY/repos/repo' for a self-hosted instance
+func New() fetcher.Fetcher {
+	return Client{
+		client:    &http.Client{Transport: transport.New()},
+		instances: forge.Instances(hostsEnv, publicHost),
+		cloudAPI:  defaultCloudAPI,
+	}
+}
+
+// Detect returns true for the URLs of repositories on bitbucket.org and the self-hosted instances
+func (c Client) Detect(name string) bool {
+	_, _, ok := forge.Find(c.instances, name)
+	return ok
+}
+
+// Host returns the host of the instance
+func (c Client) Host(name string) string {
+	instance, _, ok := forge.Find(c.instances, name)
+	if !ok {
+		return publicHost
+	}
+	return instance.Host
+}
+
+type cloudTags struct {
+	Values []struct {
+		Name   string `json:"name"`
+		Target struct {
+			Date time.Time `json:"date"`
+		} `json:"target"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+type serverTags struct {
+	Values []struct {
+		DisplayID string `json:"displayId"`
+	} `json:"values"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+// AllVersions returns the tags of the repository, Bitbucket doesn't have releases
+func (c Client) AllVersions(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	instance, repo, ok := forge.Find(c.instances, name)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a repository on %s or a host in %s", name, publicHost, hostsEnv)
+	}
+	// the web URL of a self-hosted repository has extra parts, ie. 'projects/KEY/repos/repo/browse'
+	parts := strings.Split(repo, "/")
+	if len(parts) >= 4 && parts[0] == "projects" && parts[2] == "repos" {
+		parts = []string{parts[1], parts[3]}
+	}
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%q is not a valid Bitbucket workspace/repo", repo)
+	}
+	header := http.Header{}
+	if instance.Token != "" {
+		// app passwords are used with the username, access tokens on their own
+		if i := strings.Index(instance.Token, ":"); i > 0 {
+			header.Set("Authorization", "Basic "+basicAuth(instance.Token[:i], instance.Token[i+1:]))
+		} else {
+			header.Set("Authorization", "Bearer "+instance.Token)
+		}
+	}
+
+	tags := func(ctx context.Context) ([]forge.Ref, error) {
+		var refs []forge.Ref
+		var err error
+		if instance.Host == publicHost {
+			refs, err = c.cloudTags(ctx, parts[0], parts[1], header)
+		} else {
+			refs, err = c.serverTags(ctx, instance.BaseURL, parts[0], parts[1], header)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not get tags: %v", err)
+		}
+		return refs, nil
+	}
+
+	return forge.Versions(ctx, nil, tags, opts)
+}
+
+// cloudTags follows the 'next' URL of each page, the date of the tagged commit is the publication time
+func (c Client) cloudTags(ctx context.Context, workspace, repo string, header http.Header) ([]forge.Ref, error) {
+	var refs []forge.Ref
+	next := fmt.Sprintf("%s/repositories/%s/%s/refs/tags?pagelen=%d", c.cloudAPI, url.PathEscape(workspace), url.PathEscape(repo), perPage)
+	for next != "" {
+		page := &cloudTags{}
+		if err := transport.GetJSON(ctx, c.client, next, header, page); err != nil {
+			return nil, err
+		}
+		for _, tag := range page.Values {
+			refs = append(refs, forge.Ref{Name: tag.Name, Published: tag.Target.Date})
+		}
+		next = page.Next
+	}
+	return refs, nil
+}
+
+// serverTags pages with the 'start' of each page until the last one
+func (c Client) serverTags(ctx context.Context, baseURL, project, repo string, header http.Header) ([]forge.Ref, error) {
+	var refs []forge.Ref
+	api := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/tags", baseURL, url.PathEscape(project), url.PathEscape(repo))
+	start := 0
+	for {
+		page := &serverTags{}
+		if err := transport.GetJSON(ctx, c.client, fmt.Sprintf("%s?limit=%d&start=%d", api, perPage, start), header, page); err != nil {
+			return nil, err
+		}
+		for _, tag := range page.Values {
+			refs = append(refs, forge.Ref{Name: tag.DisplayID})
+		}
+		if page.IsLastPage || page.NextPageStart <= start {
+			return refs, nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+func (c Client) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all versions: %v", err)
+	}
+
+	return versions.Latest(), nil
+}
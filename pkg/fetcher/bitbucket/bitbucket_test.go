@@ -0,0 +1,75 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/fetcher/forge"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestAllVersions(t *testing.T) {
+	var authorization string
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/2.0/repositories/workspace/repo/refs/tags":
+			if r.URL.Query().Get("page") == "" {
+				fmt.Fprintf(w, `{"values": [{"name": "v1.0.0", "target": {"date": "2020-09-01T10:00:00+00:00"}}], "next": "%s/2.0/repositories/workspace/repo/refs/tags?page=2"}`, ts.URL)
+				return
+			}
+			w.Write([]byte(`{"values": [{"name": "v1.1.0"}]}`))
+		case "/rest/api/1.0/projects/KEY/repos/repo/tags":
+			if r.URL.Query().Get("start") == "0" {
+				w.Write([]byte(`{"values": [{"displayId": "v2.0.0"}], "isLastPage": false, "nextPageStart": 1}`))
+				return
+			}
+			w.Write([]byte(`{"values": [{"displayId": "v2.1.0"}], "isLastPage": true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := Client{
+		client: http.DefaultClient,
+		instances: map[string]forge.Instance{
+			publicHost:              {Host: publicHost, BaseURL: "https://" + publicHost, Token: "user:app-password"},
+			"bitbucket.example.com": {Host: "bitbucket.example.com", BaseURL: ts.URL, Token: "secret"},
+		},
+		cloudAPI: ts.URL + "/2.0",
+	}
+
+	versions, err := client.AllVersions(context.Background(), "https://bitbucket.org/workspace/repo", fetcher.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"v1.0.0", "v1.1.0"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if authorization != "Basic "+basicAuth("user", "app-password") {
+		t.Errorf("expected basic auth with the app password, instead got %q", authorization)
+	}
+
+	versions, err = client.AllVersions(context.Background(), ts.URL+"/projects/KEY/repos/repo/browse", fetcher.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []versioned.Versioned{"v2.0.0", "v2.1.0"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("expected the access token to be sent, instead got %q", authorization)
+	}
+
+	if _, err := client.AllVersions(context.Background(), "https://bitbucket.org/workspace/repo", fetcher.Options{Source: fetcher.SourceReleases}); err == nil {
+		t.Errorf("expected an error for releases")
+	}
+}
@@ -0,0 +1,95 @@
+package crates
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const (
+	// baseURLEnv overrides the registry, ie. an internal mirror that serves the crates.io API
+	baseURLEnv     = "GOFER_CRATES_URL"
+	defaultBaseURL = "https://crates.io"
+
+	cratePrefix = "https://crates.io/crates/"
+)
+
+type Client struct {
+	client  *http.Client
+	baseURL string
+}
+
+// New returns a dependency fetcher for Rust crates
+func New() fetcher.Fetcher {
+	baseURL := os.Getenv(baseURLEnv)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return Client{client: &http.Client{Transport: transport.New()}, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Detect returns true for the URLs of crates.io crate pages
+func (c Client) Detect(name string) bool {
+	return strings.HasPrefix(name, cratePrefix)
+}
+
+// Host returns the registry host, all crates are retrieved from it
+func (c Client) Host(_ string) string {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return c.baseURL
+	}
+	return u.Host
+}
+
+type crate struct {
+	Versions []struct {
+		Num       string    `json:"num"`
+		Yanked    bool      `json:"yanked"`
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"versions"`
+}
+
+// AllVersions returns the versions of the crate that were not yanked, the current version is always kept
+func (c Client) AllVersions(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, cratePrefix), "/")
+	doc := &crate{}
+	// crates.io rejects requests without a user agent, GetJSON always sets one
+	if err := transport.GetJSON(ctx, c.client, fmt.Sprintf("%s/api/v1/crates/%s", c.baseURL, url.PathEscape(name)), nil, doc); err != nil {
+		if transport.IsNotFound(err) {
+			return nil, fmt.Errorf("crate %q was not found", name)
+		}
+		return nil, err
+	}
+
+	versions := &versioned.Versions{}
+	for _, version := range doc.Versions {
+		if version.Yanked && version.Num != opts.Version {
+			continue
+		}
+		versions.List = append(versions.List, versioned.Versioned(version.Num))
+		versions.SetMetadata(versioned.Versioned(version.Num), versioned.Metadata{Published: version.CreatedAt})
+	}
+	if len(versions.List) == 0 {
+		return nil, fetcher.ErrEmptyVerionsList
+	}
+
+	return fetcher.Filter(versions, opts)
+}
+
+func (c Client) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all versions: %v", err)
+	}
+
+	return versions.Latest(), nil
+}
@@ -0,0 +1,50 @@
+package crates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestAllVersions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/crates/serde" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("User-Agent") == "" {
+			http.Error(w, "a user agent is required", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{
+  "versions": [
+    {"num": "1.0.117", "yanked": false, "created_at": "2020-10-15T21:03:55.181306+00:00"},
+    {"num": "1.0.116", "yanked": true, "created_at": "2020-09-11T18:51:51.151208+00:00"},
+    {"num": "1.0.115", "yanked": false, "created_at": "2020-08-10T22:49:13.839201+00:00"}
+  ]
+}`))
+	}))
+	defer ts.Close()
+	client := Client{client: http.DefaultClient, baseURL: ts.URL}
+
+	versions, err := client.AllVersions(context.Background(), "https://crates.io/crates/serde", fetcher.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"1.0.115", "1.0.117"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if versions.Metadata["1.0.117"].Published.IsZero() {
+		t.Errorf("expected a publication time")
+	}
+
+	if _, err := client.AllVersions(context.Background(), "missing", fetcher.Options{}); err == nil {
+		t.Errorf("expected an error for a missing crate")
+	}
+}
@@ -1,14 +1,53 @@
 package fetcher
 
 import (
+	"context"
 	"errors"
+	"time"
+
 	"github.com/dkoshkin/gofer/pkg/versioned"
 )
 
 var ErrEmptyVerionsList = errors.New("no versions were retrieved")
 
+// Sources of versions for projects hosted on a forge, ie. Github or GitLab
+// When the source is not set releases are used, falling back to tags when the project has no releases
+const (
+	SourceReleases = "releases"
+	SourceTags     = "tags"
+	SourceBoth     = "both"
+)
+
+// ValidSources lists the supported values for the 'source' of a dependency
+var ValidSources = []string{SourceReleases, SourceTags, SourceBoth}
+
 // Fetcher retrieves information for a resource
+// Requests must be canceled when the context is done
 type Fetcher interface {
-	AllVersions(source, mask string) (versions *versioned.Versions, err error)
-	LatestVersion(source, mask string) (version *versioned.Versioned, err error)
+	AllVersions(ctx context.Context, name string, opts Options) (versions *versioned.Versions, err error)
+	LatestVersion(ctx context.Context, name string, opts Options) (version *versioned.Versioned, err error)
+}
+
+// Publisher is implemented by fetchers that can look up when a version was published
+// It is only called for versions without a publication time in their metadata, as it may require more requests
+type Publisher interface {
+	PublishedAt(ctx context.Context, name string, version versioned.Versioned) (time.Time, error)
+}
+
+// Options are the per dependency settings used when retrieving versions
+type Options struct {
+	// Mask is a regex to match the versions, leave blank to match any version
+	Mask string
+	// Constraint is a semver range to match the versions, ie. "^1.17", can be combined with Mask
+	Constraint string
+	// Source selects where the versions are read from for fetchers that support more than one
+	Source string
+	// Prerelease is the policy for pre-release versions, ie. "exclude", "include" or "only"
+	Prerelease string
+	// Version is the current version, it is required by the update policies other than "major" and "pinned"
+	Version string
+	// UpdatePolicy limits the versions to updates of the current version, ie. "patch", "minor", "major" or "pinned"
+	UpdatePolicy string
+	// Ignore excludes versions that are equal to or match the regex of any of the patterns
+	Ignore []string
 }
@@ -1,10 +1,14 @@
 package docker
 
 import (
+	"context"
 	"fmt"
+	"time"
+
 	"github.com/dkoshkin/gofer/pkg/fetcher"
 	"github.com/dkoshkin/gofer/pkg/registry"
 	"github.com/dkoshkin/gofer/pkg/versioned"
+	parser "github.com/novln/docker-parser"
 )
 
 type Client struct {
@@ -15,9 +19,18 @@ func New() fetcher.Fetcher {
 	return Client{}
 }
 
-func (c Client) AllVersions(image, mask string) (*versioned.Versions, error) {
+// Host returns the registry hostname of the image
+func (c Client) Host(image string) string {
+	parsed, err := parser.Parse(image)
+	if err != nil {
+		return image
+	}
+	return parsed.Registry()
+}
+
+func (c Client) AllVersions(ctx context.Context, image string, opts fetcher.Options) (*versioned.Versions, error) {
 	dc := registry.New()
-	tags, err := dc.Tags(image)
+	tags, err := dc.Tags(ctx, image)
 	if err != nil {
 		return nil, err
 	}
@@ -26,13 +39,17 @@ func (c Client) AllVersions(image, mask string) (*versioned.Versions, error) {
 	}
 
 	versions := versioned.FromStringSlice(tags)
-	filtered := versioned.Filter(versions, mask)
 
-	return filtered, nil
+	return fetcher.Filter(versions, opts)
+}
+
+// PublishedAt returns when the image for the tag was created
+func (c Client) PublishedAt(ctx context.Context, image string, version versioned.Versioned) (time.Time, error) {
+	return registry.New().Created(ctx, image, version.String())
 }
 
-func (c Client) LatestVersion(url, mask string) (*versioned.Versioned, error) {
-	versions, err := c.AllVersions(url, mask)
+func (c Client) LatestVersion(ctx context.Context, image string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, image, opts)
 	if err != nil {
 		return nil, fmt.Errorf("could not list all tags: %v", err)
 	}
@@ -0,0 +1,148 @@
+package fetcher
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+// Policies for pre-release versions, pre-releases are excluded when not set
+const (
+	PrereleaseExclude = "exclude"
+	PrereleaseInclude = "include"
+	PrereleaseOnly    = "only"
+)
+
+// ValidPrereleasePolicies lists the supported values for the 'prerelease' of a dependency
+var ValidPrereleasePolicies = []string{PrereleaseExclude, PrereleaseInclude, PrereleaseOnly}
+
+// Policies for updates relative to the current version, any version is allowed when not set
+const (
+	// UpdatePolicyMajor allows any version
+	UpdatePolicyMajor = "major"
+	// UpdatePolicyMinor allows versions with the same major version
+	UpdatePolicyMinor = "minor"
+	// UpdatePolicyPatch allows versions with the same major and minor versions
+	UpdatePolicyPatch = "patch"
+	// UpdatePolicyPinned only allows the current version
+	UpdatePolicyPinned = "pinned"
+)
+
+// ValidUpdatePolicies lists the supported values for the 'updatePolicy' of a dependency
+var ValidUpdatePolicies = []string{UpdatePolicyPatch, UpdatePolicyMinor, UpdatePolicyMajor, UpdatePolicyPinned}
+
+// Filter returns the versions that match the options
+// Fetchers should run all of the versions they retrieve through it
+func Filter(versions *versioned.Versions, opts Options) (*versioned.Versions, error) {
+	filtered := versioned.Filter(versions, opts.Mask)
+
+	// a pinned version is never updated, the other settings don't apply
+	if opts.UpdatePolicy == UpdatePolicyPinned {
+		return versioned.FilterFunc(filtered, func(v versioned.Versioned) bool {
+			return string(v) == opts.Version
+		}), nil
+	}
+
+	if opts.Constraint != "" {
+		constraint, err := versioned.ParseConstraint(opts.Constraint)
+		if err != nil {
+			return nil, err
+		}
+		filtered = versioned.FilterFunc(filtered, constraint.Check)
+	}
+
+	if len(opts.Ignore) > 0 {
+		if err := validIgnorePatterns(opts.Ignore); err != nil {
+			return nil, err
+		}
+		filtered = versioned.FilterFunc(filtered, func(v versioned.Versioned) bool {
+			return !Ignored(v.String(), opts.Ignore)
+		})
+	}
+
+	allowed, err := updatePolicy(opts.UpdatePolicy, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+	if allowed != nil {
+		filtered = versioned.FilterFunc(filtered, allowed)
+	}
+
+	switch opts.Prerelease {
+	case "", PrereleaseExclude:
+		filtered = versioned.FilterFunc(filtered, func(v versioned.Versioned) bool {
+			return !filtered.IsPrerelease(v)
+		})
+	case PrereleaseInclude:
+	case PrereleaseOnly:
+		filtered = versioned.FilterFunc(filtered, filtered.IsPrerelease)
+	default:
+		return nil, fmt.Errorf("unsupported prerelease policy %q", opts.Prerelease)
+	}
+
+	return notBelow(filtered, opts.Version), nil
+}
+
+// notBelow removes the versions lower than the current version so that the latest version is never a downgrade
+// The current version is kept when only lower versions are left, ie. with a constraint that excludes it
+// Versions are only compared when the current version is a semantic version
+func notBelow(versions *versioned.Versions, version string) *versioned.Versions {
+	current, err := versioned.ParseSemver(version)
+	if err != nil {
+		return versions
+	}
+	filtered := versioned.FilterFunc(versions, func(v versioned.Versioned) bool {
+		sv, err := v.Semver()
+		return err != nil || sv.Compare(*current) >= 0
+	})
+	if len(filtered.List) == 0 && len(versions.List) > 0 {
+		filtered.List = []versioned.Versioned{versioned.Versioned(version)}
+	}
+	return filtered
+}
+
+// updatePolicy returns a func that matches the versions allowed by the policy, nil when all versions are allowed
+func updatePolicy(policy, version string) (func(v versioned.Versioned) bool, error) {
+	switch policy {
+	case "", UpdatePolicyMajor:
+		return nil, nil
+	case UpdatePolicyMinor, UpdatePolicyPatch:
+	default:
+		return nil, fmt.Errorf("unsupported update policy %q", policy)
+	}
+	current, err := versioned.ParseSemver(version)
+	if err != nil {
+		return nil, fmt.Errorf("update policy %q requires a semantic version: %v", policy, err)
+	}
+	return func(v versioned.Versioned) bool {
+		sv, err := v.Semver()
+		if err != nil || sv.Major != current.Major {
+			return false
+		}
+		return policy == UpdatePolicyMinor || sv.Minor == current.Minor
+	}, nil
+}
+
+// Ignored returns true when the version is equal to or matches the regex of any of the patterns
+// Invalid patterns only match equal versions
+func Ignored(version string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if version == pattern {
+			return true
+		}
+		if rgx, err := regexp.Compile(fmt.Sprintf("^%s$", pattern)); err == nil && rgx.MatchString(version) {
+			return true
+		}
+	}
+	return false
+}
+
+func validIgnorePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(fmt.Sprintf("^%s$", pattern)); err != nil {
+			return fmt.Errorf("invalid ignore pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
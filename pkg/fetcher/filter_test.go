@@ -0,0 +1,130 @@
+package fetcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		metadata map[versioned.Versioned]versioned.Metadata
+		opts     Options
+		expected []versioned.Versioned
+	}{
+		{
+			name:     "exclude pre-releases by default",
+			versions: []string{"v1.17.5", "v1.18.0-rc.1", "v1.17.6"},
+			expected: []versioned.Versioned{"v1.17.5", "v1.17.6"},
+		},
+		{
+			name:     "exclude pre-releases marked by the source",
+			versions: []string{"v1.17.5", "v1.18.0", "v1.17.6"},
+			metadata: map[versioned.Versioned]versioned.Metadata{"v1.18.0": {Prerelease: true}},
+			opts:     Options{Prerelease: PrereleaseExclude},
+			expected: []versioned.Versioned{"v1.17.5", "v1.17.6"},
+		},
+		{
+			name:     "include pre-releases",
+			versions: []string{"v1.17.5", "v1.18.0-rc.1", "v1.17.6"},
+			opts:     Options{Prerelease: PrereleaseInclude},
+			expected: []versioned.Versioned{"v1.17.5", "v1.17.6", "v1.18.0-rc.1"},
+		},
+		{
+			name:     "only pre-releases",
+			versions: []string{"v1.17.5", "v1.18.0-rc.1", "v1.17.6", "v1.18.0"},
+			metadata: map[versioned.Versioned]versioned.Metadata{"v1.18.0": {Prerelease: true}},
+			opts:     Options{Prerelease: PrereleaseOnly},
+			expected: []versioned.Versioned{"v1.18.0-rc.1", "v1.18.0"},
+		},
+		{
+			name:     "constraint",
+			versions: []string{"v1.16.9", "v1.17.3", "v1.17.4", "v1.18.0", "v2.0.0"},
+			opts:     Options{Constraint: "^1.17 !=1.17.4"},
+			expected: []versioned.Versioned{"v1.17.3", "v1.18.0"},
+		},
+		{
+			name:     "constraint and mask",
+			versions: []string{"v1.16.9", "v1.17.3", "v1.17.4", "v1.18.0", "v2.0.0"},
+			opts:     Options{Mask: "v1.17.[0-9]+", Constraint: ">=1.16 <2"},
+			expected: []versioned.Versioned{"v1.17.3", "v1.17.4"},
+		},
+		{
+			name:     "patch updates",
+			versions: []string{"v1.16.9", "v1.17.3", "v1.17.4", "v1.17.5-rc.1", "v1.18.0", "v2.0.0", "latest"},
+			opts:     Options{Version: "v1.17.3", UpdatePolicy: UpdatePolicyPatch},
+			expected: []versioned.Versioned{"v1.17.3", "v1.17.4"},
+		},
+		{
+			name:     "minor updates",
+			versions: []string{"v1.16.9", "v1.17.3", "v1.17.4", "v1.18.0", "v2.0.0", "latest"},
+			opts:     Options{Version: "1.17", UpdatePolicy: UpdatePolicyMinor},
+			expected: []versioned.Versioned{"v1.17.3", "v1.17.4", "v1.18.0"},
+		},
+		{
+			name:     "major updates",
+			versions: []string{"v1.16.9", "v1.17.3", "v2.0.0"},
+			opts:     Options{Version: "v1.17.3", UpdatePolicy: UpdatePolicyMajor},
+			expected: []versioned.Versioned{"v1.17.3", "v2.0.0"},
+		},
+		{
+			name:     "never lower than the current version",
+			versions: []string{"v1.16.9", "v1.17.2", "v1.18.0"},
+			opts:     Options{Version: "v1.17.3", UpdatePolicy: UpdatePolicyMinor, Constraint: "<1.18"},
+			expected: []versioned.Versioned{"v1.17.3"},
+		},
+		{
+			name:     "pinned",
+			versions: []string{"v1.17.3", "v1.17.4-rc.1", "v1.17.4"},
+			opts:     Options{Version: "v1.17.4-rc.1", UpdatePolicy: UpdatePolicyPinned},
+			expected: []versioned.Versioned{"v1.17.4-rc.1"},
+		},
+		{
+			name:     "ignore",
+			versions: []string{"v1.17.3", "v1.17.4", "v1.18.0", "v1.18.1", "v2.0.0"},
+			opts:     Options{Ignore: []string{"v1.17.4", "v2.*"}},
+			expected: []versioned.Versioned{"v1.17.3", "v1.18.0", "v1.18.1"},
+		},
+		{
+			name:     "mask and pre-releases",
+			versions: []string{"v1.17.5", "v1.17.6-rc.0", "v1.18.0"},
+			opts:     Options{Mask: "v1.17.*"},
+			expected: []versioned.Versioned{"v1.17.5"},
+		},
+		{
+			name:     "mask and included pre-releases",
+			versions: []string{"v1.17.5", "v1.18.0-rc.1", "v1.18.0-rc.2"},
+			opts:     Options{Mask: `v[0-9.]+-rc\.[0-9]+`, Prerelease: PrereleaseInclude},
+			expected: []versioned.Versioned{"v1.18.0-rc.1", "v1.18.0-rc.2"},
+		},
+	}
+
+	for _, test := range tests {
+		versions := versioned.FromStringSlice(test.versions)
+		versions.Metadata = test.metadata
+		filtered, err := Filter(versions, test.opts)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(filtered.List, test.expected) {
+			t.Errorf("%s: expected %q, instead got %q", test.name, test.expected, filtered.List)
+		}
+	}
+
+	if _, err := Filter(versioned.FromStringSlice([]string{"v1.0.0"}), Options{Prerelease: "sometimes"}); err == nil {
+		t.Errorf("expected an error for an unsupported prerelease policy")
+	}
+	if _, err := Filter(versioned.FromStringSlice([]string{"v1.0.0"}), Options{Ignore: []string{"v1.("}}); err == nil {
+		t.Errorf("expected an error for an invalid ignore pattern")
+	}
+	if _, err := Filter(versioned.FromStringSlice([]string{"v1.0.0"}), Options{UpdatePolicy: "sometimes"}); err == nil {
+		t.Errorf("expected an error for an unsupported update policy")
+	}
+	if _, err := Filter(versioned.FromStringSlice([]string{"v1.0.0"}), Options{Version: "latest", UpdatePolicy: UpdatePolicyPatch}); err == nil {
+		t.Errorf("expected an error for an update policy without a semantic version")
+	}
+}
@@ -0,0 +1,160 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+// tokenEnvPrefix is followed by the hostname of an instance, ie. 'GOFER_TOKEN_gitlab_example_com'
+const tokenEnvPrefix = "GOFER_TOKEN_"
+
+// Instance is a server hosting projects, ie. 'gitlab.com' or a self-hosted Gitea
+type Instance struct {
+	Host string
+	// BaseURL is the web URL of the instance, project names start with it and the API is relative to it
+	BaseURL string
+	Token   string
+}
+
+// TokenEnv returns the name of the variable with the token for the host, dots and colons are replaced with '_' and dashes with '__'
+func TokenEnv(host string) string {
+	return tokenEnvPrefix + strings.NewReplacer(".", "_", "-", "__", ":", "_").Replace(host)
+}
+
+// Instances returns the public hosts and the self-hosted instances listed in the variable
+// The variable is a comma separated list of hostnames or 'hostname=baseURL' for instances not served from the root, ie.
+// 'git.example.com,code.example.com=https://code.example.com/gitea'
+func Instances(env string, public ...string) map[string]Instance {
+	hosts := append([]string{}, public...)
+	if value := os.Getenv(env); value != "" {
+		hosts = append(hosts, strings.Split(value, ",")...)
+	}
+	instances := make(map[string]Instance, len(hosts))
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		baseURL := "https://" + host
+		if i := strings.Index(host, "="); i > 0 {
+			host, baseURL = host[:i], host[i+1:]
+		}
+		instances[host] = Instance{
+			Host:    host,
+			BaseURL: strings.TrimSuffix(baseURL, "/"),
+			Token:   os.Getenv(TokenEnv(host)),
+		}
+	}
+	return instances
+}
+
+// Find returns the instance the name belongs to and the path of the project in it
+// The name is the URL of the project with or without the scheme, ie. 'https://gitlab.com/group/project'
+func Find(instances map[string]Instance, name string) (Instance, string, bool) {
+	name = withoutScheme(strings.TrimSuffix(name, "/"))
+	var found Instance
+	var prefix string
+	for _, instance := range instances {
+		p := withoutScheme(instance.BaseURL) + "/"
+		// the longest base URL wins when instances share a host
+		if strings.HasPrefix(name, p) && len(p) > len(prefix) {
+			found, prefix = instance, p
+		}
+	}
+	if prefix == "" {
+		return Instance{}, "", false
+	}
+	project := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".git")
+	return found, project, project != ""
+}
+
+func withoutScheme(u string) string {
+	if i := strings.Index(u, "://"); i >= 0 {
+		return u[i+3:]
+	}
+	return u
+}
+
+// Ref is a release or a tag of a project
+type Ref struct {
+	Name       string
+	Prerelease bool
+	Published  time.Time
+}
+
+// Lister returns the releases or the tags of a project
+type Lister func(ctx context.Context) ([]Ref, error)
+
+// Versions returns the versions from the source in the options for all of the forges, ie. Github or GitLab
+// When the source is not set releases are used, falling back to tags when the project has no releases
+// releases can be nil for forges that only have tags
+func Versions(ctx context.Context, releases, tags Lister, opts fetcher.Options) (*versioned.Versions, error) {
+	source := opts.Source
+	if source == "" && releases == nil {
+		source = fetcher.SourceTags
+	}
+
+	var refs []Ref
+	var err error
+	switch source {
+	case "", fetcher.SourceReleases, fetcher.SourceBoth:
+		if releases == nil {
+			return nil, fmt.Errorf("source %q is not supported, only tags are", source)
+		}
+		refs, err = releases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// fallback to tags for projects that never publish releases
+		if source == fetcher.SourceBoth || (len(refs) == 0 && source == "") {
+			var tagRefs []Ref
+			tagRefs, err = tags(ctx)
+			refs = append(refs, tagRefs...)
+		}
+	case fetcher.SourceTags:
+		refs, err = tags(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported source %q", source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	versions := &versioned.Versions{}
+	seen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		// releases come first, their metadata is kept for tags with the same name
+		if seen[ref.Name] {
+			continue
+		}
+		seen[ref.Name] = true
+		versions.List = append(versions.List, versioned.Versioned(ref.Name))
+		versions.SetMetadata(versioned.Versioned(ref.Name), versioned.Metadata{Prerelease: ref.Prerelease, Published: ref.Published})
+	}
+	if len(versions.List) == 0 {
+		return nil, fetcher.ErrEmptyVerionsList
+	}
+
+	return fetcher.Filter(versions, opts)
+}
+
+// Pages calls get with the URL of every page of a list until a page is empty, get returns the length of the page
+// The page size is set with the sizeParam, ie. 'per_page' for GitLab or 'limit' for Gitea,
+// a server may return fewer items than requested on every page, ie. when Gitea's MAX_RESPONSE_ITEMS is lower
+func Pages(u, sizeParam string, size int, get func(pageURL string) (int, error)) error {
+	for page := 1; ; page++ {
+		n, err := get(fmt.Sprintf("%s?%s=%d&page=%d", u, sizeParam, size, page))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package forge
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestInstances(t *testing.T) {
+	os.Setenv("GOFER_TEST_HOSTS", "git.example.com, code.example.com=https://code.example.com/gitea/")
+	defer os.Unsetenv("GOFER_TEST_HOSTS")
+	os.Setenv(TokenEnv("code.example.com"), "secret")
+	defer os.Unsetenv(TokenEnv("code.example.com"))
+
+	instances := Instances("GOFER_TEST_HOSTS", "gitea.com")
+	expected := map[string]Instance{
+		"gitea.com":        {Host: "gitea.com", BaseURL: "https://gitea.com"},
+		"git.example.com":  {Host: "git.example.com", BaseURL: "https://git.example.com"},
+		"code.example.com": {Host: "code.example.com", BaseURL: "https://code.example.com/gitea", Token: "secret"},
+	}
+	if !reflect.DeepEqual(instances, expected) {
+		t.Errorf("expected instances %+v, instead got %+v", expected, instances)
+	}
+	if env := TokenEnv("my-git.example.com"); env != "GOFER_TOKEN_my__git_example_com" {
+		t.Errorf("unexpected variable %q", env)
+	}
+}
+
+func TestFind(t *testing.T) {
+	instances := map[string]Instance{
+		"gitlab.com":       {Host: "gitlab.com", BaseURL: "https://gitlab.com"},
+		"code.example.com": {Host: "code.example.com", BaseURL: "https://code.example.com/gitlab"},
+	}
+	tests := []struct {
+		name    string
+		host    string
+		project string
+	}{
+		{name: "https://gitlab.com/gitlab-org/gitlab-runner", host: "gitlab.com", project: "gitlab-org/gitlab-runner"},
+		{name: "gitlab.com/group/subgroup/project.git", host: "gitlab.com", project: "group/subgroup/project"},
+		{name: "https://code.example.com/gitlab/group/project/", host: "code.example.com", project: "group/project"},
+		{name: "https://github.com/dkoshkin/gofer"},
+		{name: "registry.gitlab.com/group/image"},
+		{name: "https://gitlab.com/"},
+	}
+	for _, test := range tests {
+		instance, project, ok := Find(instances, test.name)
+		if ok != (test.host != "") {
+			t.Errorf("expected %q to be found %t, instead got %t", test.name, test.host != "", ok)
+			continue
+		}
+		if ok && (instance.Host != test.host || project != test.project) {
+			t.Errorf("expected %q in %q for %q, instead got %q in %q", test.project, test.host, test.name, project, instance.Host)
+		}
+	}
+}
+
+func TestVersions(t *testing.T) {
+	published := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	releases := func(context.Context) ([]Ref, error) {
+		return []Ref{{Name: "v1.1.0-rc.1", Prerelease: true}, {Name: "v1.0.0", Published: published}}, nil
+	}
+	noReleases := func(context.Context) ([]Ref, error) {
+		return nil, nil
+	}
+	tags := func(context.Context) ([]Ref, error) {
+		return []Ref{{Name: "v0.9.0"}, {Name: "v1.0.0"}}, nil
+	}
+	tests := []struct {
+		releases Lister
+		source   string
+		expected []versioned.Versioned
+	}{
+		{releases: releases, expected: []versioned.Versioned{"v1.0.0"}},
+		{releases: noReleases, expected: []versioned.Versioned{"v0.9.0", "v1.0.0"}},
+		{releases: releases, source: fetcher.SourceTags, expected: []versioned.Versioned{"v0.9.0", "v1.0.0"}},
+		{releases: releases, source: fetcher.SourceBoth, expected: []versioned.Versioned{"v0.9.0", "v1.0.0"}},
+		{releases: nil, expected: []versioned.Versioned{"v0.9.0", "v1.0.0"}},
+	}
+	for _, test := range tests {
+		versions, err := Versions(context.Background(), test.releases, tags, fetcher.Options{Source: test.source})
+		if err != nil {
+			t.Errorf("unexpected error for source %q: %v", test.source, err)
+			continue
+		}
+		if !reflect.DeepEqual(versions.List, test.expected) {
+			t.Errorf("expected versions %v for source %q, instead got %v", test.expected, test.source, versions.List)
+		}
+	}
+
+	// the metadata of a release is kept for a tag with the same name
+	versions, _ := Versions(context.Background(), releases, tags, fetcher.Options{Source: fetcher.SourceBoth})
+	if !versions.Metadata["v1.0.0"].Published.Equal(published) {
+		t.Errorf("expected the release metadata, instead got %+v", versions.Metadata["v1.0.0"])
+	}
+
+	if _, err := Versions(context.Background(), nil, tags, fetcher.Options{Source: fetcher.SourceReleases}); err == nil {
+		t.Errorf("expected an error for releases of a forge that only has tags")
+	}
+}
+
+func TestPages(t *testing.T) {
+	// the server returns fewer items than requested, ie. a lower MAX_RESPONSE_ITEMS in Gitea
+	pages := map[string]int{
+		"https://gitea.example.com/api?limit=50&page=1": 10,
+		"https://gitea.example.com/api?limit=50&page=2": 10,
+		"https://gitea.example.com/api?limit=50&page=3": 3,
+	}
+	total := 0
+	err := Pages("https://gitea.example.com/api", "limit", 50, func(pageURL string) (int, error) {
+		n, ok := pages[pageURL]
+		if !ok && pageURL != "https://gitea.example.com/api?limit=50&page=4" {
+			t.Errorf("unexpected page %q", pageURL)
+		}
+		total += n
+		return n, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 23 {
+		t.Errorf("expected the items of every page, instead got %d", total)
+	}
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+// pktLines encodes the lines in the pkt-line format, an empty line is a flush packet
+func pktLines(lines ...string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		if line == "" {
+			b.WriteString("0000")
+			continue
+		}
+		fmt.Fprintf(&b, "%04x%s", len(line)+4, line)
+	}
+	return b.String()
+}
+
+// the refs advertised by 'git upload-pack' for a lightweight tag and an annotated tag
+var testAdvertisement = pktLines(
+	"# service=git-upload-pack\n",
+	"",
+	"4ef62cfa574f342bc837e87970b5bbf8c1453b5b HEAD\x00multi_ack thin-pack side-band symref=HEAD:refs/heads/master agent=git/2.39.5\n",
+	"4ef62cfa574f342bc837e87970b5bbf8c1453b5b refs/heads/master\n",
+	"a057fe12e5cc4168e3c322cf31445491fee300a5 refs/tags/v1.0.0\n",
+	"9028f8c673a642029eda2981bc09a5bd855837ba refs/tags/v1.1.0\n",
+	"4ef62cfa574f342bc837e87970b5bbf8c1453b5b refs/tags/v1.1.0^{}\n",
+	"9028f8c673a642029eda2981bc09a5bd855837bb refs/tags/docs-1\n",
+	"",
+)
+
+const testInfoRefs = "4ef62cfa574f342bc837e87970b5bbf8c1453b5b\trefs/heads/master\n" +
+	"a057fe12e5cc4168e3c322cf31445491fee300a5\trefs/tags/v1.0.0\n" +
+	"9028f8c673a642029eda2981bc09a5bd855837ba\trefs/tags/v1.1.0\n" +
+	"4ef62cfa574f342bc837e87970b5bbf8c1453b5b\trefs/tags/v1.1.0^{}\n"
+
+func TestTags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/smart.git/info/refs":
+			if r.URL.Query().Get("service") != "git-upload-pack" {
+				http.Error(w, "missing service", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", advertisementType)
+			w.Write([]byte(testAdvertisement))
+		case "/dumb.git/info/refs":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(testInfoRefs))
+		case "/error.git/info/refs":
+			w.Header().Set("Content-Type", advertisementType)
+			w.Write([]byte(pktLines("ERR access denied\n")))
+		default:
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+	client := Client{client: http.DefaultClient}
+
+	expected := map[string]string{
+		"v1.0.0": "a057fe12e5cc4168e3c322cf31445491fee300a5",
+		// the annotated tag is peeled to its commit
+		"v1.1.0": "4ef62cfa574f342bc837e87970b5bbf8c1453b5b",
+		"docs-1": "9028f8c673a642029eda2981bc09a5bd855837bb",
+	}
+	tags, err := client.Tags(context.Background(), ts.URL+"/smart.git")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected tags %v, instead got %v", expected, tags)
+	}
+
+	delete(expected, "docs-1")
+	tags, err = client.Tags(context.Background(), ts.URL+"/dumb.git/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected tags %v from the dumb protocol, instead got %v", expected, tags)
+	}
+
+	for _, name := range []string{ts.URL + "/error.git", ts.URL + "/private.git"} {
+		if _, err := client.Tags(context.Background(), name); err == nil {
+			t.Errorf("expected an error for %q", name)
+		}
+	}
+
+	// credentials in the repository URL are never part of an error, errors are stored in the manifest
+	withCredentials := strings.Replace(ts.URL, "http://", "http://user:secret@", 1)
+	for _, name := range []string{withCredentials + "/private.git", withCredentials + "/error.git"} {
+		_, err := client.Tags(context.Background(), name)
+		if err == nil || strings.Contains(err.Error(), "secret") {
+			t.Errorf("expected an error without the password, instead got %v", err)
+		}
+	}
+}
+
+func TestAllVersions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", advertisementType)
+		w.Write([]byte(testAdvertisement))
+	}))
+	defer ts.Close()
+	client := Client{client: http.DefaultClient}
+
+	versions, err := client.AllVersions(context.Background(), ts.URL+"/smart.git", fetcher.Options{Mask: `v1\.[0-9]+\.[0-9]+`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"v1.0.0", "v1.1.0"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+}
@@ -0,0 +1,133 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/fetcher/forge"
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const (
+	// hostsEnv lists self-hosted instances, ie. 'gitea.example.com,code.example.com=https://code.example.com/gitea'
+	hostsEnv = "GOFER_GITEA_HOSTS"
+
+	// the default max page size of the Gitea API
+	perPage = 50
+)
+
+// publicHosts are the public instances, Forgejo serves the same API
+var publicHosts = []string{"gitea.com", "codeberg.org"}
+
+type Client struct {
+	client    *http.Client
+	instances map[string]forge.Instance
+}
+
+// New returns a dependency fetcher for Gitea repositories
+// The name is the URL of the repository, ie. 'https://gitea.com/gitea/tea'
+func New() fetcher.Fetcher {
+	return Client{client: &http.Client{Transport: transport.New()}, instances: forge.Instances(hostsEnv, publicHosts...)}
+}
+
+// Detect returns true for the URLs of repositories on the public and the self-hosted instances
+func (c Client) Detect(name string) bool {
+	_, _, ok := forge.Find(c.instances, name)
+	return ok
+}
+
+// Host returns the host of the instance
+func (c Client) Host(name string) string {
+	instance, _, ok := forge.Find(c.instances, name)
+	if !ok {
+		return publicHosts[0]
+	}
+	return instance.Host
+}
+
+type release struct {
+	TagName     string    `json:"tag_name"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type tag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		Created time.Time `json:"created"`
+	} `json:"commit"`
+}
+
+// AllVersions returns the releases or tags of the repository, see forge.Versions
+// Draft releases are skipped
+func (c Client) AllVersions(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	instance, repo, ok := forge.Find(c.instances, name)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a repository on %s or a host in %s", name, strings.Join(publicHosts, ", "), hostsEnv)
+	}
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%q is not a valid Gitea owner/repo", repo)
+	}
+	api := fmt.Sprintf("%s/api/v1/repos/%s/%s", instance.BaseURL, url.PathEscape(parts[0]), url.PathEscape(parts[1]))
+	header := http.Header{}
+	if instance.Token != "" {
+		header.Set("Authorization", "token "+instance.Token)
+	}
+
+	releases := func(ctx context.Context) ([]forge.Ref, error) {
+		var refs []forge.Ref
+		err := forge.Pages(api+"/releases", "limit", perPage, func(pageURL string) (int, error) {
+			var list []release
+			if err := transport.GetJSON(ctx, c.client, pageURL, header, &list); err != nil {
+				return 0, err
+			}
+			for _, r := range list {
+				if r.Draft {
+					continue
+				}
+				refs = append(refs, forge.Ref{Name: r.TagName, Prerelease: r.Prerelease, Published: r.PublishedAt})
+			}
+			return len(list), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not get releases: %v", err)
+		}
+		return refs, nil
+	}
+	tags := func(ctx context.Context) ([]forge.Ref, error) {
+		var refs []forge.Ref
+		err := forge.Pages(api+"/tags", "limit", perPage, func(pageURL string) (int, error) {
+			var list []tag
+			if err := transport.GetJSON(ctx, c.client, pageURL, header, &list); err != nil {
+				return 0, err
+			}
+			for _, t := range list {
+				refs = append(refs, forge.Ref{Name: t.Name, Published: t.Commit.Created})
+			}
+			return len(list), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not get tags: %v", err)
+		}
+		return refs, nil
+	}
+
+	return forge.Versions(ctx, releases, tags, opts)
+}
+
+func (c Client) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all versions: %v", err)
+	}
+
+	return versions.Latest(), nil
+}
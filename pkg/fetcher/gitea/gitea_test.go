@@ -0,0 +1,70 @@
+package gitea
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/fetcher/forge"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestAllVersions(t *testing.T) {
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		// the lists are paged until an empty page
+		if r.URL.Query().Get("page") != "1" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/repos/gitea/tea/releases":
+			w.Write([]byte(`[
+				{"tag_name": "v0.6.0", "draft": true},
+				{"tag_name": "v0.5.0-rc1", "prerelease": true, "published_at": "2020-09-20T10:00:00Z"},
+				{"tag_name": "v0.4.1", "published_at": "2020-09-01T10:00:00Z"}
+			]`))
+		case "/api/v1/repos/gitea/tea/tags":
+			w.Write([]byte(`[{"name": "v0.6.0", "commit": {"created": "2020-10-01T10:00:00Z"}}, {"name": "v0.4.1"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := Client{
+		client:    http.DefaultClient,
+		instances: map[string]forge.Instance{"gitea.example.com": {Host: "gitea.example.com", BaseURL: ts.URL, Token: "secret"}},
+	}
+	name := ts.URL + "/gitea/tea"
+
+	versions, err := client.AllVersions(context.Background(), name, fetcher.Options{Prerelease: fetcher.PrereleaseInclude})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"v0.4.1", "v0.5.0-rc1"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if !versions.IsPrerelease("v0.5.0-rc1") {
+		t.Errorf("expected v0.5.0-rc1 to be a pre-release")
+	}
+	if authorization != "token secret" {
+		t.Errorf("expected the token to be sent, instead got %q", authorization)
+	}
+
+	latest, err := client.LatestVersion(context.Background(), name, fetcher.Options{Source: fetcher.SourceTags})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *latest != "v0.6.0" {
+		t.Errorf("expected the latest tag v0.6.0, instead got %s", *latest)
+	}
+
+	if _, err := client.AllVersions(context.Background(), ts.URL+"/gitea/tea/src/branch/main", fetcher.Options{}); err == nil {
+		t.Errorf("expected an error for a name that is not owner/repo")
+	}
+}
@@ -7,15 +7,24 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	gh "github.com/google/go-github/v31/github"
 	"golang.org/x/oauth2"
 
+	"github.com/dkoshkin/gofer/pkg/fetcher/forge"
+	"github.com/dkoshkin/gofer/pkg/transport"
 	"github.com/dkoshkin/gofer/pkg/versioned"
 )
 
 const (
 	githubTokeneEnv = "GITHUB_ACCESS_TOKEN"
+
+	githubPrefix      = "https://github.com/"
+	githubPrefixShort = "github.com/"
+
+	// max page size allowed by the Github API
+	perPage = 100
 )
 
 type Client struct {
@@ -25,11 +34,12 @@ type Client struct {
 
 // New returns a dependency fetcher for github
 func New() fetcher.Fetcher {
-	var tc *http.Client
+	// retry failed requests and wait for the rate limit to reset
+	tc := &http.Client{Transport: transport.New()}
 	// use client token if provided
 	token := os.Getenv(githubTokeneEnv)
 	if token != "" {
-		ctx := context.Background()
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, tc)
 		ts := oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: token},
 		)
@@ -40,32 +50,126 @@ func New() fetcher.Fetcher {
 	return Client{github: client, token: token}
 }
 
-func (c Client) AllVersions(url, mask string) (*versioned.Versions, error) {
-	project, err := projectFromURL(url)
+// Detect returns true for Github project URLs
+func (c Client) Detect(url string) bool {
+	return strings.HasPrefix(url, githubPrefix) || strings.HasPrefix(url, githubPrefixShort)
+}
+
+// Host returns the API host, all projects share the same rate limit
+func (c Client) Host(_ string) string {
+	return c.github.BaseURL.Host
+}
+
+// AllVersions returns the releases or tags of the project, see forge.Versions
+func (c Client) AllVersions(ctx context.Context, url string, opts fetcher.Options) (*versioned.Versions, error) {
+	owner, repo, err := ownerRepo(url)
 	if err != nil {
 		return nil, err
 	}
-	ownerRepoPair := strings.Split(project, "/")
-	if len(ownerRepoPair) != 2 {
-		return nil, fmt.Errorf("%q not a valid Github owner:repo format", project)
+
+	releases := func(ctx context.Context) ([]forge.Ref, error) {
+		releases, err := c.releases(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		refs := make([]forge.Ref, 0, len(releases))
+		for _, release := range releases {
+			refs = append(refs, forge.Ref{
+				Name:       release.GetTagName(),
+				Prerelease: release.GetPrerelease(),
+				Published:  release.GetPublishedAt().Time,
+			})
+		}
+		return refs, nil
 	}
-	releases, _, err := c.github.Repositories.ListReleases(context.Background(), ownerRepoPair[0], ownerRepoPair[1], nil)
-	if err != nil {
-		return nil, fmt.Errorf("could not get versions :%v", err)
+	tags := func(ctx context.Context) ([]forge.Ref, error) {
+		tags, err := c.tags(ctx, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		refs := make([]forge.Ref, 0, len(tags))
+		for _, tag := range tags {
+			refs = append(refs, forge.Ref{Name: tag})
+		}
+		return refs, nil
 	}
-	if len(releases) == 0 {
-		return nil, fetcher.ErrEmptyVerionsList
+
+	return forge.Versions(ctx, releases, tags, opts)
+}
+
+// releases returns all published releases, drafts are skipped
+func (c Client) releases(ctx context.Context, owner, repo string) ([]*gh.RepositoryRelease, error) {
+	out := make([]*gh.RepositoryRelease, 0)
+	listOptions := &gh.ListOptions{PerPage: perPage}
+	for {
+		releases, resp, err := c.github.Repositories.ListReleases(ctx, owner, repo, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("could not get releases: %v", err)
+		}
+		for _, release := range releases {
+			if release.GetDraft() {
+				continue
+			}
+			out = append(out, release)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOptions.Page = resp.NextPage
 	}
-	tags := toStringSlice(releases)
+	return out, nil
+}
 
-	versions := versioned.FromStringSlice(tags)
-	filtered := versioned.Filter(versions, mask)
+func (c Client) tags(ctx context.Context, owner, repo string) ([]string, error) {
+	out := make([]string, 0)
+	listOptions := &gh.ListOptions{PerPage: perPage}
+	for {
+		tags, resp, err := c.github.Repositories.ListTags(ctx, owner, repo, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("could not get tags: %v", err)
+		}
+		for _, tag := range tags {
+			out = append(out, tag.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOptions.Page = resp.NextPage
+	}
+	return out, nil
+}
 
-	return filtered, nil
+// PublishedAt returns when the tag was created, the tagger date for annotated tags or the commit date for lightweight tags
+// Releases already have a publication time in their metadata
+func (c Client) PublishedAt(ctx context.Context, url string, version versioned.Versioned) (time.Time, error) {
+	owner, repo, err := ownerRepo(url)
+	if err != nil {
+		return time.Time{}, err
+	}
+	ref, _, err := c.github.Git.GetRef(ctx, owner, repo, "tags/"+version.String())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not get tag %q: %v", version, err)
+	}
+	object := ref.GetObject()
+	switch object.GetType() {
+	case "tag":
+		tag, _, err := c.github.Git.GetTag(ctx, owner, repo, object.GetSHA())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not get tag %q: %v", version, err)
+		}
+		return tag.GetTagger().GetDate(), nil
+	case "commit":
+		commit, _, err := c.github.Git.GetCommit(ctx, owner, repo, object.GetSHA())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not get commit for tag %q: %v", version, err)
+		}
+		return commit.GetCommitter().GetDate(), nil
+	}
+	return time.Time{}, fmt.Errorf("tag %q points to an unsupported %q object", version, object.GetType())
 }
 
-func (c Client) LatestVersion(url, mask string) (*versioned.Versioned, error) {
-	versions, err := c.AllVersions(url, mask)
+func (c Client) LatestVersion(ctx context.Context, url string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, url, opts)
 	if err != nil {
 		return nil, fmt.Errorf("could not list all tags: %v", err)
 	}
@@ -73,19 +177,22 @@ func (c Client) LatestVersion(url, mask string) (*versioned.Versioned, error) {
 	return versions.Latest(), nil
 }
 
-func projectFromURL(url string) (string, error) {
-	if url == "" {
-		return "", fmt.Errorf("invalid Github URL %q", url)
+func ownerRepo(url string) (string, string, error) {
+	project, err := projectFromURL(url)
+	if err != nil {
+		return "", "", err
 	}
-	trimmed := strings.TrimLeft(url, "https://")
-	return strings.TrimLeft(trimmed, "github.com/"), nil
+	ownerRepoPair := strings.Split(project, "/")
+	if len(ownerRepoPair) != 2 {
+		return "", "", fmt.Errorf("%q not a valid Github owner:repo format", project)
+	}
+	return ownerRepoPair[0], ownerRepoPair[1], nil
 }
 
-func toStringSlice(releases []*gh.RepositoryRelease) []string {
-	out := make([]string, 0)
-	for n := range releases {
-		out = append(out, *releases[n].TagName)
+func projectFromURL(url string) (string, error) {
+	if url == "" {
+		return "", fmt.Errorf("invalid Github URL %q", url)
 	}
-
-	return out
+	trimmed := strings.TrimPrefix(url, "https://")
+	return strings.TrimPrefix(trimmed, githubPrefixShort), nil
 }
@@ -0,0 +1,152 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v31/github"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func testClient(t *testing.T, handler http.HandlerFunc) (Client, func()) {
+	ts := httptest.NewServer(handler)
+	client := gh.NewClient(nil)
+	baseURL, err := url.Parse(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = baseURL
+	return Client{github: client}, ts.Close
+}
+
+func TestAllVersions(t *testing.T) {
+	var serverURL string
+	client, closeServer := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if r.URL.Query().Get("per_page") != fmt.Sprint(perPage) {
+			t.Errorf("expected %d items per page, instead got %q", perPage, r.URL.Query().Get("per_page"))
+		}
+		switch r.URL.Path {
+		case "/repos/owner/project/releases":
+			// the releases are split over two pages
+			if page == "" || page == "1" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=%d&page=2>; rel="next"`, serverURL, r.URL.Path, perPage))
+				w.Write([]byte(`[
+					{"tag_name": "v1.2.0", "draft": true},
+					{"tag_name": "v1.1.0-rc.1", "prerelease": true, "published_at": "2020-09-20T10:00:00Z"},
+					{"tag_name": "v1.0.0", "published_at": "2020-09-01T10:00:00Z"}
+				]`))
+				return
+			}
+			w.Write([]byte(`[{"tag_name": "v0.9.0", "published_at": "2020-08-01T10:00:00Z"}]`))
+		case "/repos/owner/project/tags":
+			w.Write([]byte(`[{"name": "v1.0.0"}, {"name": "v0.8.0"}]`))
+		case "/repos/owner/tags-only/releases":
+			w.Write([]byte(`[]`))
+		case "/repos/owner/tags-only/tags":
+			w.Write([]byte(`[{"name": "1.1"}, {"name": "1.2"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer closeServer()
+	serverURL = strings.TrimSuffix(client.github.BaseURL.String(), "/")
+
+	tests := []struct {
+		name     string
+		source   string
+		expected []versioned.Versioned
+	}{
+		{name: "https://github.com/owner/project", expected: []versioned.Versioned{"v0.9.0", "v1.0.0"}},
+		{name: "https://github.com/owner/project", source: fetcher.SourceReleases, expected: []versioned.Versioned{"v0.9.0", "v1.0.0"}},
+		{name: "https://github.com/owner/project", source: fetcher.SourceTags, expected: []versioned.Versioned{"v0.8.0", "v1.0.0"}},
+		{name: "https://github.com/owner/project", source: fetcher.SourceBoth, expected: []versioned.Versioned{"v0.8.0", "v0.9.0", "v1.0.0"}},
+		// projects without releases fall back to their tags
+		{name: "github.com/owner/tags-only", expected: []versioned.Versioned{"1.1", "1.2"}},
+	}
+	for _, test := range tests {
+		versions, err := client.AllVersions(context.Background(), test.name, fetcher.Options{Source: test.source})
+		if err != nil {
+			t.Errorf("%s %q: unexpected error: %v", test.name, test.source, err)
+			continue
+		}
+		if !reflect.DeepEqual(versions.List, test.expected) {
+			t.Errorf("%s %q: expected versions %v, instead got %v", test.name, test.source, test.expected, versions.List)
+		}
+	}
+
+	// pre-releases are marked by their release, drafts are skipped
+	versions, err := client.AllVersions(context.Background(), "https://github.com/owner/project", fetcher.Options{Prerelease: fetcher.PrereleaseOnly})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []versioned.Versioned{"v1.1.0-rc.1"}; !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if published := versions.Metadata["v1.0.0"].Published; !published.Equal(time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the release publication time, instead got %v", published)
+	}
+
+	if _, err := client.AllVersions(context.Background(), "https://github.com/owner/project", fetcher.Options{Source: "branches"}); err == nil {
+		t.Errorf("expected an error for an unsupported source")
+	}
+	if _, err := client.AllVersions(context.Background(), "https://github.com/owner/missing", fetcher.Options{}); err == nil {
+		t.Errorf("expected an error for a missing project")
+	}
+}
+
+func TestPublishedAt(t *testing.T) {
+	client, closeServer := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/project/git/refs/tags/v1.0.0":
+			w.Write([]byte(`{"ref": "refs/tags/v1.0.0", "object": {"type": "tag", "sha": "aaa"}}`))
+		case "/repos/owner/project/git/tags/aaa":
+			w.Write([]byte(`{"tag": "v1.0.0", "tagger": {"date": "2020-09-01T10:00:00Z"}}`))
+		case "/repos/owner/project/git/refs/tags/v0.9.0":
+			w.Write([]byte(`{"ref": "refs/tags/v0.9.0", "object": {"type": "commit", "sha": "bbb"}}`))
+		case "/repos/owner/project/git/commits/bbb":
+			w.Write([]byte(`{"sha": "bbb", "committer": {"date": "2020-08-01T10:00:00Z"}}`))
+		case "/repos/owner/project/git/refs/tags/v0.8.0":
+			w.Write([]byte(`{"ref": "refs/tags/v0.8.0", "object": {"type": "blob", "sha": "ccc"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer closeServer()
+
+	tests := []struct {
+		version  versioned.Versioned
+		expected time.Time
+	}{
+		// annotated tags use the tagger date
+		{version: "v1.0.0", expected: time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC)},
+		// lightweight tags use the commit date
+		{version: "v0.9.0", expected: time.Date(2020, 8, 1, 10, 0, 0, 0, time.UTC)},
+		// unsupported objects and missing tags are errors
+		{version: "v0.8.0"},
+		{version: "v0.7.0"},
+	}
+	for _, test := range tests {
+		published, err := client.PublishedAt(context.Background(), "https://github.com/owner/project", test.version)
+		if test.expected.IsZero() {
+			if err == nil {
+				t.Errorf("%s: expected an error, instead got %v", test.version, published)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.version, err)
+		} else if !published.Equal(test.expected) {
+			t.Errorf("%s: expected %v, instead got %v", test.version, test.expected, published)
+		}
+	}
+}
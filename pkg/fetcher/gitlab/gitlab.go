@@ -0,0 +1,126 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/fetcher/forge"
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const (
+	// hostsEnv lists self-hosted instances, ie. 'gitlab.example.com,code.example.com=https://code.example.com/gitlab'
+	hostsEnv   = "GOFER_GITLAB_HOSTS"
+	publicHost = "gitlab.com"
+
+	// max page size allowed by the GitLab API
+	perPage = 100
+)
+
+type Client struct {
+	client    *http.Client
+	instances map[string]forge.Instance
+}
+
+// New returns a dependency fetcher for GitLab projects
+// The name is the URL of the project, ie. 'https://gitlab.com/gitlab-org/gitlab-runner', projects can be in subgroups
+func New() fetcher.Fetcher {
+	return Client{client: &http.Client{Transport: transport.New()}, instances: forge.Instances(hostsEnv, publicHost)}
+}
+
+// Detect returns true for the URLs of projects on gitlab.com and the self-hosted instances
+func (c Client) Detect(name string) bool {
+	_, _, ok := forge.Find(c.instances, name)
+	return ok
+}
+
+// Host returns the host of the instance
+func (c Client) Host(name string) string {
+	instance, _, ok := forge.Find(c.instances, name)
+	if !ok {
+		return publicHost
+	}
+	return instance.Host
+}
+
+type release struct {
+	TagName         string    `json:"tag_name"`
+	ReleasedAt      time.Time `json:"released_at"`
+	UpcomingRelease bool      `json:"upcoming_release"`
+}
+
+type tag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"commit"`
+}
+
+// AllVersions returns the releases or tags of the project, see forge.Versions
+// Upcoming releases are skipped, they are not released yet
+func (c Client) AllVersions(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	instance, project, ok := forge.Find(c.instances, name)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a project on %s or a host in %s", name, publicHost, hostsEnv)
+	}
+	// the project path is used as its ID, the slashes must be escaped
+	api := fmt.Sprintf("%s/api/v4/projects/%s", instance.BaseURL, url.PathEscape(project))
+	header := http.Header{}
+	if instance.Token != "" {
+		header.Set("PRIVATE-TOKEN", instance.Token)
+	}
+
+	releases := func(ctx context.Context) ([]forge.Ref, error) {
+		var refs []forge.Ref
+		err := forge.Pages(api+"/releases", "per_page", perPage, func(pageURL string) (int, error) {
+			var list []release
+			if err := transport.GetJSON(ctx, c.client, pageURL, header, &list); err != nil {
+				return 0, err
+			}
+			for _, r := range list {
+				if r.UpcomingRelease {
+					continue
+				}
+				refs = append(refs, forge.Ref{Name: r.TagName, Published: r.ReleasedAt})
+			}
+			return len(list), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not get releases: %v", err)
+		}
+		return refs, nil
+	}
+	tags := func(ctx context.Context) ([]forge.Ref, error) {
+		var refs []forge.Ref
+		err := forge.Pages(api+"/repository/tags", "per_page", perPage, func(pageURL string) (int, error) {
+			var list []tag
+			if err := transport.GetJSON(ctx, c.client, pageURL, header, &list); err != nil {
+				return 0, err
+			}
+			for _, t := range list {
+				refs = append(refs, forge.Ref{Name: t.Name, Published: t.Commit.CreatedAt})
+			}
+			return len(list), nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not get tags: %v", err)
+		}
+		return refs, nil
+	}
+
+	return forge.Versions(ctx, releases, tags, opts)
+}
+
+func (c Client) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all versions: %v", err)
+	}
+
+	return versions.Latest(), nil
+}
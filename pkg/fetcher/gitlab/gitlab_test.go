@@ -0,0 +1,85 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/fetcher/forge"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestAllVersions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// the project path is escaped, RawPath keeps it as sent
+		switch r.URL.EscapedPath() {
+		case "/gitlab/api/v4/projects/group%2Fsubgroup%2Fproject/releases":
+			if r.URL.Query().Get("page") != "1" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"tag_name": "v1.2.0", "upcoming_release": true}, {"tag_name": "v1.1.0", "released_at": "2020-10-01T10:00:00Z"}]`))
+		case "/gitlab/api/v4/projects/group%2Fsubgroup%2Fproject/repository/tags":
+			// the first page is full, the second one is not and the third one is empty
+			switch r.URL.Query().Get("page") {
+			case "1":
+				var tags []string
+				for i := 0; i < perPage; i++ {
+					tags = append(tags, fmt.Sprintf(`{"name": "v0.%d.0"}`, i))
+				}
+				fmt.Fprintf(w, "[%s]", strings.Join(tags, ","))
+			case "2":
+				w.Write([]byte(`[{"name": "v1.1.0", "commit": {"created_at": "2020-09-30T10:00:00Z"}}]`))
+			default:
+				w.Write([]byte(`[]`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := Client{
+		client:    http.DefaultClient,
+		instances: map[string]forge.Instance{"code.example.com": {Host: "code.example.com", BaseURL: ts.URL + "/gitlab", Token: "secret"}},
+	}
+	name := ts.URL + "/gitlab/group/subgroup/project"
+	if !client.Detect(name) || client.Host(name) != "code.example.com" {
+		t.Errorf("expected %q to be detected on its instance", name)
+	}
+
+	versions, err := client.AllVersions(context.Background(), name, fetcher.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"v1.1.0"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if versions.Metadata["v1.1.0"].Published.IsZero() {
+		t.Errorf("expected the release date to be set")
+	}
+
+	versions, err = client.AllVersions(context.Background(), name, fetcher.Options{Source: fetcher.SourceTags})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(versions.List) != perPage+1 {
+		t.Errorf("expected the tags of both pages, instead got %d", len(versions.List))
+	}
+
+	if _, err := client.AllVersions(context.Background(), ts.URL+"/gitlab/group/missing", fetcher.Options{}); err == nil {
+		t.Errorf("expected an error for a missing project")
+	}
+	if _, err := client.AllVersions(context.Background(), "https://github.com/dkoshkin/gofer", fetcher.Options{}); err == nil {
+		t.Errorf("expected an error for a project on another host")
+	}
+}
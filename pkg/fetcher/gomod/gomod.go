@@ -0,0 +1,319 @@
+package gomod
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const (
+	goProxyEnv   = "GOPROXY"
+	goPrivateEnv = "GOPRIVATE"
+	goNoProxyEnv = "GONOPROXY"
+
+	defaultGoProxy = "https://proxy.golang.org,direct"
+
+	// maxMajorProbes limits how many newer major version paths are looked up, ie. '/v3', '/v4'
+	maxMajorProbes = 10
+)
+
+var errNotFound = errors.New("not found")
+
+// proxy is an entry in the GOPROXY list
+type proxy struct {
+	url string
+	// fallThrough is true when the next proxy is tried after any error, not only when the module is not found, ie. "proxy1|proxy2"
+	fallThrough bool
+}
+
+type Client struct {
+	client  *http.Client
+	proxies []proxy
+	// noProxy are the GONOPROXY glob patterns of module paths that are not fetched from a proxy
+	noProxy []string
+}
+
+// New returns a dependency fetcher for Go modules that uses the GOPROXY protocol
+// It honours the GOPROXY, GONOPROXY and GOPRIVATE settings, modules that must be fetched 'direct' from version control are not supported
+func New() fetcher.Fetcher {
+	goProxy := os.Getenv(goProxyEnv)
+	if goProxy == "" {
+		goProxy = defaultGoProxy
+	}
+	noProxy := os.Getenv(goNoProxyEnv)
+	if noProxy == "" {
+		noProxy = os.Getenv(goPrivateEnv)
+	}
+	return Client{
+		client:  &http.Client{Transport: transport.New()},
+		proxies: parseGoProxy(goProxy),
+		noProxy: splitPatterns(noProxy),
+	}
+}
+
+// parseGoProxy parses a list of proxies separated by ',' or '|'
+func parseGoProxy(in string) []proxy {
+	var proxies []proxy
+	for in != "" {
+		i := strings.IndexAny(in, ",|")
+		entry, fallThrough := in, false
+		if i >= 0 {
+			entry, fallThrough = in[:i], in[i] == '|'
+			in = in[i+1:]
+		} else {
+			in = ""
+		}
+		if entry = strings.TrimSpace(entry); entry != "" {
+			proxies = append(proxies, proxy{url: strings.TrimSuffix(entry, "/"), fallThrough: fallThrough})
+		}
+	}
+	return proxies
+}
+
+func splitPatterns(in string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(in, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// matchesPatterns reports whether any prefix of the module path matches a glob pattern, the same way as GOPRIVATE
+func matchesPatterns(module string, patterns []string) bool {
+	for _, pattern := range patterns {
+		n := strings.Count(pattern, "/") + 1
+		elements := strings.Split(module, "/")
+		if len(elements) < n {
+			continue
+		}
+		if matched, _ := path.Match(pattern, strings.Join(elements[:n], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Host returns the host of the first proxy, all modules are retrieved from it
+func (c Client) Host(_ string) string {
+	for _, p := range c.proxies {
+		if u, err := url.Parse(p.url); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return ""
+}
+
+func (c Client) AllVersions(ctx context.Context, module string, opts fetcher.Options) (*versioned.Versions, error) {
+	list, err := c.list(ctx, module)
+	if err == errNotFound {
+		return nil, fmt.Errorf("module %q was not found", module)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// modules without tagged versions only have a pseudo-version
+	if len(list) == 0 {
+		info, err := c.info(ctx, module, "@latest")
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, info.Version)
+	}
+
+	// a new major version has a new module path, ie. 'example.com/mod/v3'
+	highest := majorVersion(module)
+	for _, v := range list {
+		if sv, err := versioned.ParseSemver(v); err == nil && sv.Major > highest {
+			highest = sv.Major
+		}
+	}
+	for i := 0; i < maxMajorProbes; i++ {
+		next, ok := withMajorVersion(module, highest+1)
+		if !ok {
+			break
+		}
+		// newer major versions are optional, some proxies reject unknown modules with other errors than 'not found'
+		newer, err := c.list(ctx, next)
+		if err != nil || len(newer) == 0 {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			break
+		}
+		list = append(list, newer...)
+		highest++
+	}
+
+	if len(list) == 0 {
+		return nil, fetcher.ErrEmptyVerionsList
+	}
+	return fetcher.Filter(versioned.FromStringSlice(list), opts)
+}
+
+func (c Client) LatestVersion(ctx context.Context, module string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, module, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all versions: %v", err)
+	}
+
+	return versions.Latest(), nil
+}
+
+// PublishedAt returns the time of the version reported by the proxy
+func (c Client) PublishedAt(ctx context.Context, module string, version versioned.Versioned) (time.Time, error) {
+	// the version may be from a newer major version path
+	if sv, err := version.Semver(); err == nil && sv.Major > majorVersion(module) && sv.Build != "incompatible" {
+		if newer, ok := withMajorVersion(module, sv.Major); ok {
+			module = newer
+		}
+	}
+	info, err := c.info(ctx, module, "@v/"+escape(version.String())+".info")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.Time, nil
+}
+
+// list returns the tagged versions of the module
+func (c Client) list(ctx context.Context, module string) ([]string, error) {
+	body, err := c.get(ctx, module, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		// some proxies add the time after the version
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			list = append(list, fields[0])
+		}
+	}
+	return list, scanner.Err()
+}
+
+type info struct {
+	Version string
+	Time    time.Time
+}
+
+func (c Client) info(ctx context.Context, module, endpoint string) (*info, error) {
+	body, err := c.get(ctx, module, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	i := &info{}
+	if err := json.Unmarshal(body, i); err != nil {
+		return nil, fmt.Errorf("could not parse the response for %q: %v", module, err)
+	}
+	return i, nil
+}
+
+// get requests the endpoint of the module from each proxy until one has the module
+func (c Client) get(ctx context.Context, module, endpoint string) ([]byte, error) {
+	if matchesPatterns(module, c.noProxy) {
+		return nil, fmt.Errorf("%q matches GONOPROXY or GOPRIVATE, fetching modules directly from version control is not supported", module)
+	}
+	err := fmt.Errorf("GOPROXY is empty")
+	for _, p := range c.proxies {
+		switch p.url {
+		case "off":
+			return nil, fmt.Errorf("module lookups are disabled by GOPROXY=off")
+		case "direct":
+			if err == errNotFound {
+				return nil, err
+			}
+			return nil, fmt.Errorf("fetching %q directly from version control is not supported, set GOPROXY to a module proxy", module)
+		}
+		var body []byte
+		body, err = c.fetch(ctx, p.url+"/"+escape(module)+"/"+endpoint)
+		if err == nil {
+			return body, nil
+		}
+		if err != errNotFound && !p.fallThrough {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (c Client) fetch(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, errNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %q from %q: %s", resp.Status, u, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// escape replaces upper case letters with '!' and the lower case letter, as required by the GOPROXY protocol
+func escape(in string) string {
+	var b strings.Builder
+	for _, r := range in {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var (
+	majorSuffixRgx = regexp.MustCompile(`/v([0-9]+)$`)
+	gopkgInRgx     = regexp.MustCompile(`^(gopkg\.in/.+)\.v([0-9]+)(-unstable)?$`)
+)
+
+// majorVersion returns the major version of the module path, ie. 3 for 'example.com/mod/v3' and 1 without a suffix
+func majorVersion(module string) int64 {
+	if match := gopkgInRgx.FindStringSubmatch(module); match != nil {
+		major, _ := strconv.ParseInt(match[2], 10, 64)
+		return major
+	}
+	if match := majorSuffixRgx.FindStringSubmatch(module); match != nil {
+		major, _ := strconv.ParseInt(match[1], 10, 64)
+		return major
+	}
+	return 1
+}
+
+// withMajorVersion returns the module path for a major version of 2 or more, false when the path can't have major versions
+func withMajorVersion(module string, major int64) (string, bool) {
+	if match := gopkgInRgx.FindStringSubmatch(module); match != nil {
+		return fmt.Sprintf("%s.v%d", match[1], major), true
+	}
+	if strings.HasPrefix(module, "gopkg.in/") {
+		return "", false
+	}
+	return fmt.Sprintf("%s/v%d", majorSuffixRgx.ReplaceAllString(module, ""), major), true
+}
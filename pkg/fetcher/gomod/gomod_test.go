@@ -0,0 +1,120 @@
+package gomod
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func testProxy() *httptest.Server {
+	responses := map[string]string{
+		"/github.com/!burnt!sushi/toml/@v/list":        "v0.1.0\nv0.3.1\n",
+		"/github.com/spf13/cobra/@v/list":              "v0.0.5\nv1.0.0\nv1.1.0 2020-10-01T00:00:00Z\n",
+		"/github.com/go-redis/redis/@v/list":           "v6.15.9+incompatible\nv6.15.8+incompatible\n",
+		"/github.com/go-redis/redis/v7/@v/list":        "v7.4.0\n",
+		"/github.com/go-redis/redis/v8/@v/list":        "v8.3.2\n",
+		"/github.com/go-redis/redis/v8/@v/v8.3.2.info": `{"Version":"v8.3.2","Time":"2020-10-18T09:00:00Z"}`,
+		"/gopkg.in/yaml.v2/@v/list":                    "v2.3.0\n",
+		"/gopkg.in/yaml.v3/@v/list":                    "v3.0.0\n",
+		"/golang.org/x/oauth2/@v/list":                 "",
+		"/golang.org/x/oauth2/@latest":                 `{"Version":"v0.0.0-20200107190931-bf48bf16ab8d","Time":"2020-01-07T19:09:31Z"}`,
+		"/github.com/go-redis/redis/v9/@v/list":        "",
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestAllVersions(t *testing.T) {
+	ts := testProxy()
+	defer ts.Close()
+	empty := httptest.NewServer(http.NotFoundHandler())
+	defer empty.Close()
+	client := Client{client: http.DefaultClient, proxies: parseGoProxy(empty.URL + "," + ts.URL + ",direct")}
+
+	tests := []struct {
+		module   string
+		opts     fetcher.Options
+		expected []versioned.Versioned
+		err      bool
+	}{
+		{module: "github.com/BurntSushi/toml", expected: []versioned.Versioned{"v0.1.0", "v0.3.1"}},
+		{module: "github.com/spf13/cobra", expected: []versioned.Versioned{"v0.0.5", "v1.0.0", "v1.1.0"}},
+		{
+			module:   "github.com/go-redis/redis",
+			expected: []versioned.Versioned{"v6.15.8+incompatible", "v6.15.9+incompatible", "v7.4.0", "v8.3.2"},
+		},
+		{
+			module:   "github.com/go-redis/redis/v7",
+			opts:     fetcher.Options{Version: "v7.0.0", UpdatePolicy: fetcher.UpdatePolicyMinor},
+			expected: []versioned.Versioned{"v7.4.0"},
+		},
+		{module: "gopkg.in/yaml.v2", expected: []versioned.Versioned{"v2.3.0", "v3.0.0"}},
+		{module: "golang.org/x/oauth2", expected: []versioned.Versioned{"v0.0.0-20200107190931-bf48bf16ab8d"}},
+		{module: "example.com/missing", err: true},
+	}
+	for _, test := range tests {
+		versions, err := client.AllVersions(context.Background(), test.module, test.opts)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, instead got %v", test.module, versions.List)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.module, err)
+			continue
+		}
+		if !reflect.DeepEqual(versions.List, test.expected) {
+			t.Errorf("%s: expected versions %v, instead got %v", test.module, test.expected, versions.List)
+		}
+	}
+
+	published, err := client.PublishedAt(context.Background(), "github.com/go-redis/redis", "v8.3.2")
+	if err != nil || !published.Equal(time.Date(2020, 10, 18, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected publication time %s and error %v", published, err)
+	}
+}
+
+func TestGoProxySettings(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	ts := testProxy()
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		client  Client
+		success bool
+	}{
+		{name: "fall through errors with a pipe", client: Client{proxies: parseGoProxy(failing.URL + "|" + ts.URL)}, success: true},
+		{name: "stop on errors with a comma", client: Client{proxies: parseGoProxy(failing.URL + "," + ts.URL)}},
+		{name: "off", client: Client{proxies: parseGoProxy("off")}},
+		{name: "direct", client: Client{proxies: parseGoProxy("direct")}},
+		{name: "private", client: Client{proxies: parseGoProxy(ts.URL), noProxy: splitPatterns("example.com,github.com/spf13/*")}},
+		{name: "other private module", client: Client{proxies: parseGoProxy(ts.URL), noProxy: splitPatterns("github.com/spf13/viper")}, success: true},
+	}
+	for _, test := range tests {
+		test.client.client = http.DefaultClient
+		_, err := test.client.AllVersions(context.Background(), "github.com/spf13/cobra", fetcher.Options{})
+		if test.success && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.success && err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package helm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const testIndex = `apiVersion: v1
+entries:
+  nginx:
+  - version: 7.0.0
+    appVersion: 1.19.3
+    created: "2020-10-16T10:00:00.000000000Z"
+  - version: 6.2.1
+    appVersion: 1.19.2
+    created: "2020-10-01T10:00:00.000000000Z"
+    deprecated: true
+  - version: 6.2.0
+    appVersion: 1.19.2
+    created: "2020-09-20T10:00:00.000000000Z"
+  redis:
+  - version: 11.2.1
+`
+
+func TestAllVersions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bitnami/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testIndex))
+	}))
+	defer ts.Close()
+	client := Client{client: http.DefaultClient}
+
+	versions, err := client.AllVersions(context.Background(), ts.URL+"/bitnami/nginx", fetcher.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"6.2.0", "7.0.0"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if metadata := versions.Metadata["7.0.0"]; metadata.Note != "appVersion 1.19.3" || metadata.Published.IsZero() {
+		t.Errorf("unexpected metadata %+v", metadata)
+	}
+
+	// the current version is kept even when it's deprecated
+	versions, err = client.AllVersions(context.Background(), ts.URL+"/bitnami/nginx", fetcher.Options{Version: "6.2.1", UpdatePolicy: fetcher.UpdatePolicyPinned})
+	if err != nil || len(versions.List) != 1 {
+		t.Errorf("expected the deprecated current version, instead got %v and error %v", versions, err)
+	}
+
+	for _, name := range []string{ts.URL + "/bitnami/mysql", ts.URL + "/stable/nginx"} {
+		if _, err := client.AllVersions(context.Background(), name, fetcher.Options{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// credentials in the repository URL are never part of an error, errors are stored in the manifest
+	withCredentials := strings.Replace(ts.URL, "http://", "http://user:secret@", 1)
+	for _, name := range []string{withCredentials + "/stable/nginx", withCredentials + "/bitnami/mysql"} {
+		_, err := client.AllVersions(context.Background(), name, fetcher.Options{})
+		if err == nil || strings.Contains(err.Error(), "secret") {
+			t.Errorf("expected an error without the password, instead got %v", err)
+		}
+	}
+}
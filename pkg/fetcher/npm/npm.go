@@ -0,0 +1,106 @@
+package npm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const (
+	// registryEnv overrides the registry, ie. an internal mirror
+	registryEnv     = "GOFER_NPM_REGISTRY"
+	defaultRegistry = "https://registry.npmjs.org"
+
+	packagePrefix = "https://www.npmjs.com/package/"
+)
+
+type Client struct {
+	client   *http.Client
+	registry string
+}
+
+// New returns a dependency fetcher for npm packages
+func New() fetcher.Fetcher {
+	registry := os.Getenv(registryEnv)
+	if registry == "" {
+		registry = defaultRegistry
+	}
+	return Client{client: &http.Client{Transport: transport.New()}, registry: strings.TrimSuffix(registry, "/")}
+}
+
+// Detect returns true for the URLs of npmjs.com package pages
+func (c Client) Detect(name string) bool {
+	return strings.HasPrefix(name, packagePrefix)
+}
+
+// Host returns the registry host, all packages are retrieved from it
+func (c Client) Host(_ string) string {
+	u, err := url.Parse(c.registry)
+	if err != nil {
+		return c.registry
+	}
+	return u.Host
+}
+
+type packument struct {
+	Versions map[string]struct {
+		// Deprecated is the deprecation message, a few old packages use a boolean
+		Deprecated interface{} `json:"deprecated"`
+	} `json:"versions"`
+	Time map[string]time.Time `json:"time"`
+}
+
+// AllVersions returns the versions of the package that are not deprecated, the current version is always kept
+func (c Client) AllVersions(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	pkg := strings.TrimSuffix(strings.TrimPrefix(name, packagePrefix), "/")
+	// the slash of a scoped package is escaped, ie. '@types%2fnode'
+	u := c.registry + "/" + strings.Replace(pkg, "/", "%2f", 1)
+	doc := &packument{}
+	if err := transport.GetJSON(ctx, c.client, u, nil, doc); err != nil {
+		if transport.IsNotFound(err) {
+			return nil, fmt.Errorf("package %q was not found", pkg)
+		}
+		return nil, err
+	}
+
+	versions := &versioned.Versions{}
+	for version, meta := range doc.Versions {
+		if deprecated(meta.Deprecated) && version != opts.Version {
+			continue
+		}
+		versions.List = append(versions.List, versioned.Versioned(version))
+		versions.SetMetadata(versioned.Versioned(version), versioned.Metadata{Published: doc.Time[version]})
+	}
+	if len(versions.List) == 0 {
+		return nil, fetcher.ErrEmptyVerionsList
+	}
+
+	return fetcher.Filter(versions, opts)
+}
+
+func deprecated(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v != ""
+	case bool:
+		return v
+	}
+	return false
+}
+
+func (c Client) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all versions: %v", err)
+	}
+
+	return versions.Latest(), nil
+}
@@ -0,0 +1,56 @@
+package npm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestAllVersions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawPath != "/@types%2fnode" && r.URL.Path != "/@types/node" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{
+  "versions": {
+    "14.11.1": {},
+    "14.11.2": {},
+    "14.11.3": {"deprecated": "published by mistake"},
+    "14.11.4": {"deprecated": false},
+    "15.0.0-beta.1": {}
+  },
+  "time": {"created": "2016-05-17T18:04:12.000Z", "14.11.2": "2020-09-22T17:54:21.300Z"}
+}`))
+	}))
+	defer ts.Close()
+	client := Client{client: http.DefaultClient, registry: ts.URL}
+
+	versions, err := client.AllVersions(context.Background(), "https://www.npmjs.com/package/@types/node", fetcher.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"14.11.1", "14.11.2", "14.11.4"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if published := versions.Metadata["14.11.2"].Published; !published.Equal(time.Date(2020, 9, 22, 17, 54, 21, 300000000, time.UTC)) {
+		t.Errorf("unexpected publication time %s", published)
+	}
+
+	// the current version is kept even when it's deprecated
+	versions, err = client.AllVersions(context.Background(), "@types/node", fetcher.Options{Version: "14.11.3", UpdatePolicy: fetcher.UpdatePolicyPinned})
+	if err != nil || len(versions.List) != 1 || versions.List[0] != "14.11.3" {
+		t.Errorf("expected the deprecated current version, instead got %v and error %v", versions, err)
+	}
+
+	if _, err := client.AllVersions(context.Background(), "left-pad", fetcher.Options{}); err == nil {
+		t.Errorf("expected an error for a missing package")
+	}
+}
@@ -0,0 +1,102 @@
+package pypi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const (
+	// baseURLEnv overrides the index, ie. an internal mirror that serves the JSON API
+	baseURLEnv     = "GOFER_PYPI_URL"
+	defaultBaseURL = "https://pypi.org"
+
+	projectPrefix = "https://pypi.org/project/"
+)
+
+type Client struct {
+	client  *http.Client
+	baseURL string
+}
+
+// New returns a dependency fetcher for Python packages
+func New() fetcher.Fetcher {
+	baseURL := os.Getenv(baseURLEnv)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return Client{client: &http.Client{Transport: transport.New()}, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Detect returns true for the URLs of pypi.org project pages
+func (c Client) Detect(name string) bool {
+	return strings.HasPrefix(name, projectPrefix)
+}
+
+// Host returns the index host, all packages are retrieved from it
+func (c Client) Host(_ string) string {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return c.baseURL
+	}
+	return u.Host
+}
+
+type project struct {
+	Releases map[string][]struct {
+		Uploaded time.Time `json:"upload_time_iso_8601"`
+		Yanked   bool      `json:"yanked"`
+	} `json:"releases"`
+}
+
+// AllVersions returns the versions of the package that have files that were not yanked, the current version is always kept
+func (c Client) AllVersions(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	pkg := strings.TrimSuffix(strings.TrimPrefix(name, projectPrefix), "/")
+	doc := &project{}
+	if err := transport.GetJSON(ctx, c.client, fmt.Sprintf("%s/pypi/%s/json", c.baseURL, url.PathEscape(pkg)), nil, doc); err != nil {
+		if transport.IsNotFound(err) {
+			return nil, fmt.Errorf("package %q was not found", pkg)
+		}
+		return nil, err
+	}
+
+	versions := &versioned.Versions{}
+	for version, files := range doc.Releases {
+		// a release is yanked when all of its files are, a release without files can't be installed either
+		var published time.Time
+		yanked := true
+		for _, file := range files {
+			yanked = yanked && file.Yanked
+			if published.IsZero() || file.Uploaded.Before(published) {
+				published = file.Uploaded
+			}
+		}
+		if yanked && version != opts.Version {
+			continue
+		}
+		versions.List = append(versions.List, versioned.Versioned(version))
+		versions.SetMetadata(versioned.Versioned(version), versioned.Metadata{Published: published})
+	}
+	if len(versions.List) == 0 {
+		return nil, fetcher.ErrEmptyVerionsList
+	}
+
+	return fetcher.Filter(versions, opts)
+}
+
+func (c Client) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all versions: %v", err)
+	}
+
+	return versions.Latest(), nil
+}
@@ -0,0 +1,51 @@
+package pypi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestAllVersions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pypi/requests/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{
+  "releases": {
+    "2.24.0": [
+      {"upload_time_iso_8601": "2020-06-17T15:08:00.123456Z", "yanked": false},
+      {"upload_time_iso_8601": "2020-06-17T15:07:58.000000Z", "yanked": false}
+    ],
+    "2.25.0": [{"upload_time_iso_8601": "2020-11-11T19:20:00.000000Z", "yanked": true}],
+    "2.25.0rc1": [{"upload_time_iso_8601": "2020-11-01T00:00:00.000000Z", "yanked": false}],
+    "2.26.0": []
+  }
+}`))
+	}))
+	defer ts.Close()
+	client := Client{client: http.DefaultClient, baseURL: ts.URL}
+
+	versions, err := client.AllVersions(context.Background(), "https://pypi.org/project/requests/", fetcher.Options{Prerelease: fetcher.PrereleaseInclude})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"2.24.0", "2.25.0rc1"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if published := versions.Metadata["2.24.0"].Published; !published.Equal(time.Date(2020, 6, 17, 15, 7, 58, 0, time.UTC)) {
+		t.Errorf("expected the time of the first file, instead got %s", published)
+	}
+
+	if _, err := client.AllVersions(context.Background(), "missing", fetcher.Options{}); err == nil {
+		t.Errorf("expected an error for a missing package")
+	}
+}
@@ -0,0 +1,66 @@
+package fetcher
+
+// Detector is implemented by fetchers that can recognize their dependencies by name
+type Detector interface {
+	Detect(name string) bool
+}
+
+// HostResolver is implemented by fetchers to report the host a dependency is retrieved from
+// it is used to limit the number of concurrent requests made to the same host
+type HostResolver interface {
+	Host(name string) string
+}
+
+// Registry holds a Fetcher for each dependency type
+type Registry struct {
+	fetchers map[string]Fetcher
+	// preserve the order types were registered in, detection is attempted in this order
+	types    []string
+	fallback string
+}
+
+// NewRegistry returns an empty fetcher registry
+func NewRegistry() *Registry {
+	return &Registry{fetchers: make(map[string]Fetcher)}
+}
+
+// Register adds or replaces the fetcher for a dependency type
+func (r *Registry) Register(depType string, f Fetcher) {
+	if _, ok := r.fetchers[depType]; !ok {
+		r.types = append(r.types, depType)
+	}
+	r.fetchers[depType] = f
+}
+
+// RegisterFallback registers a fetcher that is used for names no other fetcher detects
+func (r *Registry) RegisterFallback(depType string, f Fetcher) {
+	r.Register(depType, f)
+	r.fallback = depType
+}
+
+// Get returns the fetcher for a dependency type
+func (r *Registry) Get(depType string) (Fetcher, bool) {
+	f, ok := r.fetchers[depType]
+	return f, ok
+}
+
+// Types returns all of the registered dependency types
+func (r *Registry) Types() []string {
+	types := make([]string, len(r.types))
+	copy(types, r.types)
+	return types
+}
+
+// Detect returns the type of the first fetcher that recognizes the name, or the fallback type
+// false is returned when the type could not be determined
+func (r *Registry) Detect(name string) (string, bool) {
+	for _, depType := range r.types {
+		if detector, ok := r.fetchers[depType].(Detector); ok && depType != r.fallback && detector.Detect(name) {
+			return depType, true
+		}
+	}
+	if r.fallback != "" {
+		return r.fallback, true
+	}
+	return "", false
+}
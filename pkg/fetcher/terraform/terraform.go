@@ -0,0 +1,181 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/transport"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+const (
+	defaultHost = "registry.terraform.io"
+
+	// tokenEnvPrefix is followed by the registry hostname, ie. 'TF_TOKEN_app_terraform_io'
+	tokenEnvPrefix = "TF_TOKEN_"
+
+	discoveryPath    = "/.well-known/terraform.json"
+	providersService = "providers.v1"
+	modulesService   = "modules.v1"
+)
+
+type Client struct {
+	client *http.Client
+}
+
+// New returns a dependency fetcher for Terraform providers and modules using the registry protocol
+// Providers are named 'namespace/type', ie. 'hashicorp/aws', and modules 'namespace/name/provider',
+// both can be prefixed with the hostname of a private registry, ie. 'app.terraform.io/example/vpc/aws'
+func New() fetcher.Fetcher {
+	return Client{client: &http.Client{Transport: transport.New()}}
+}
+
+// Detect returns true for names that start with the public registry hostname
+// Other names can't be told apart from images and must set the type
+func (c Client) Detect(name string) bool {
+	return strings.HasPrefix(name, defaultHost+"/")
+}
+
+// Host returns the hostname of the registry
+func (c Client) Host(name string) string {
+	a, err := parseAddress(name)
+	if err != nil {
+		return defaultHost
+	}
+	return a.host
+}
+
+// address is a provider or module in a registry
+type address struct {
+	host    string
+	service string
+	// path is the path of the provider or module in its service, ie. 'hashicorp/aws'
+	path string
+}
+
+func parseAddress(name string) (*address, error) {
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	a := &address{host: defaultHost}
+	// the first part is a hostname when it has a dot, the same as Terraform
+	if len(parts) > 2 && strings.Contains(parts[0], ".") {
+		a.host, parts = parts[0], parts[1:]
+	}
+	switch len(parts) {
+	case 2:
+		a.service = providersService
+	case 3:
+		a.service = modulesService
+	default:
+		return nil, fmt.Errorf("%q is not a provider 'namespace/type' or a module 'namespace/name/provider'", name)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("%q has an empty part", name)
+		}
+	}
+	a.path = strings.Join(parts, "/")
+	return a, nil
+}
+
+// tokenEnv returns the name of the variable with the token for the host, dots are replaced with '_' and dashes with '__'
+func tokenEnv(host string) string {
+	return tokenEnvPrefix + strings.NewReplacer(".", "_", "-", "__").Replace(host)
+}
+
+type providerVersions struct {
+	Versions []struct {
+		Version string `json:"version"`
+	} `json:"versions"`
+}
+
+type moduleVersions struct {
+	Modules []struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	} `json:"modules"`
+}
+
+func (c Client) AllVersions(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versions, error) {
+	a, err := parseAddress(name)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	if token := os.Getenv(tokenEnv(a.host)); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	base, err := c.discover(ctx, a, header)
+	if err != nil {
+		return nil, err
+	}
+
+	u := base + a.path + "/versions"
+	var list []string
+	if a.service == providersService {
+		resp := &providerVersions{}
+		err = transport.GetJSON(ctx, c.client, u, header, resp)
+		for _, v := range resp.Versions {
+			list = append(list, v.Version)
+		}
+	} else {
+		resp := &moduleVersions{}
+		err = transport.GetJSON(ctx, c.client, u, header, resp)
+		for _, m := range resp.Modules {
+			for _, v := range m.Versions {
+				list = append(list, v.Version)
+			}
+		}
+	}
+	if transport.IsNotFound(err) {
+		return nil, fmt.Errorf("%q was not found in %q", a.path, a.host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fetcher.ErrEmptyVerionsList
+	}
+
+	return fetcher.Filter(versioned.FromStringSlice(list), opts)
+}
+
+// discover returns the URL of the service of the address from the registry's service discovery document
+func (c Client) discover(ctx context.Context, a *address, header http.Header) (string, error) {
+	discovery, err := url.Parse("https://" + a.host + discoveryPath)
+	if err != nil {
+		return "", err
+	}
+	services := make(map[string]interface{})
+	if err := transport.GetJSON(ctx, c.client, discovery.String(), header, &services); err != nil {
+		return "", fmt.Errorf("could not discover the services of %q: %v", a.host, err)
+	}
+	service, ok := services[a.service].(string)
+	if !ok {
+		return "", fmt.Errorf("%q does not support %q", a.host, a.service)
+	}
+	// the service URL may be relative to the discovery document
+	ref, err := url.Parse(service)
+	if err != nil {
+		return "", fmt.Errorf("%q has an invalid %q URL: %v", a.host, a.service, err)
+	}
+	resolved := discovery.ResolveReference(ref).String()
+	if !strings.HasSuffix(resolved, "/") {
+		resolved += "/"
+	}
+	return resolved, nil
+}
+
+func (c Client) LatestVersion(ctx context.Context, name string, opts fetcher.Options) (*versioned.Versioned, error) {
+	versions, err := c.AllVersions(ctx, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not list all versions: %v", err)
+	}
+
+	return versions.Latest(), nil
+}
@@ -0,0 +1,101 @@
+package terraform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+	"github.com/dkoshkin/gofer/pkg/versioned"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected *address
+	}{
+		{name: "hashicorp/aws", expected: &address{host: defaultHost, service: providersService, path: "hashicorp/aws"}},
+		{name: "terraform-aws-modules/vpc/aws", expected: &address{host: defaultHost, service: modulesService, path: "terraform-aws-modules/vpc/aws"}},
+		{name: "registry.terraform.io/hashicorp/aws", expected: &address{host: defaultHost, service: providersService, path: "hashicorp/aws"}},
+		{name: "app.terraform.io/example/vpc/aws", expected: &address{host: "app.terraform.io", service: modulesService, path: "example/vpc/aws"}},
+		{name: "aws"},
+		{name: "hashicorp//aws"},
+		{name: "app.terraform.io/example/vpc/aws/extra"},
+	}
+	for _, test := range tests {
+		a, err := parseAddress(test.name)
+		if test.expected == nil {
+			if err == nil {
+				t.Errorf("expected an error for %q, instead got %+v", test.name, a)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.name, err)
+		} else if !reflect.DeepEqual(a, test.expected) {
+			t.Errorf("expected %+v for %q, instead got %+v", test.expected, test.name, a)
+		}
+	}
+}
+
+func TestTokenEnv(t *testing.T) {
+	if env := tokenEnv("app.terraform.io"); env != "TF_TOKEN_app_terraform_io" {
+		t.Errorf("unexpected variable %q", env)
+	}
+	if env := tokenEnv("my-registry.example.com"); env != "TF_TOKEN_my__registry_example_com" {
+		t.Errorf("unexpected variable %q", env)
+	}
+}
+
+func TestAllVersions(t *testing.T) {
+	var authorization string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case discoveryPath:
+			w.Write([]byte(`{"providers.v1": "/v1/providers/", "modules.v1": "/api/registry/v1/modules"}`))
+		case "/v1/providers/hashicorp/aws/versions":
+			w.Write([]byte(`{"versions": [{"version": "3.10.0"}, {"version": "3.11.0"}, {"version": "3.9.0"}]}`))
+		case "/api/registry/v1/modules/example/vpc/aws/versions":
+			w.Write([]byte(`{"modules": [{"versions": [{"version": "1.1.0"}, {"version": "1.0.0"}]}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "https://")
+	client := Client{client: ts.Client()}
+
+	versions, err := client.AllVersions(context.Background(), host+"/hashicorp/aws", fetcher.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []versioned.Versioned{"3.9.0", "3.10.0", "3.11.0"}
+	if !reflect.DeepEqual(versions.List, expected) {
+		t.Errorf("expected versions %v, instead got %v", expected, versions.List)
+	}
+	if authorization != "" {
+		t.Errorf("expected no authorization without a token, instead got %q", authorization)
+	}
+
+	os.Setenv(tokenEnv(host), "secret")
+	defer os.Unsetenv(tokenEnv(host))
+	latest, err := client.LatestVersion(context.Background(), host+"/example/vpc/aws", fetcher.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *latest != "1.1.0" {
+		t.Errorf("expected the latest module version 1.1.0, instead got %s", *latest)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("expected the token to be sent, instead got %q", authorization)
+	}
+
+	if _, err := client.AllVersions(context.Background(), host+"/hashicorp/google", fetcher.Options{}); err == nil {
+		t.Errorf("expected an error for a missing provider")
+	}
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"strings"
+)
+
+// parseChallenge parses a 'WWW-Authenticate' header value
+// ie. Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"
+// The scheme is returned lowercased along with the auth params
+func parseChallenge(header string) (string, map[string]string) {
+	params := map[string]string{}
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return "", params
+	}
+	scheme := header
+	rest := ""
+	if i := strings.IndexAny(header, " \t"); i != -1 {
+		scheme = header[:i]
+		rest = header[i+1:]
+	}
+
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			break
+		}
+		i := strings.Index(rest, "=")
+		if i == -1 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:i]))
+		rest = strings.TrimLeft(rest[i+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			// quoted value, may contain commas
+			var b strings.Builder
+			j := 1
+			for ; j < len(rest); j++ {
+				if rest[j] == '\\' && j+1 < len(rest) {
+					j++
+					b.WriteByte(rest[j])
+					continue
+				}
+				if rest[j] == '"' {
+					break
+				}
+				b.WriteByte(rest[j])
+			}
+			value = b.String()
+			if j < len(rest) {
+				j++
+			}
+			rest = rest[j:]
+		} else {
+			end := strings.Index(rest, ",")
+			if end == -1 {
+				end = len(rest)
+			}
+			value = strings.TrimSpace(rest[:end])
+			rest = rest[end:]
+		}
+		params[key] = value
+	}
+
+	return strings.ToLower(scheme), params
+}
@@ -1,27 +1,38 @@
 package registry
 
 import (
+	"context"
 	b64 "encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/dkoshkin/gofer/pkg/transport"
 )
 
 const (
-	dockerhubAuthURL = "https://auth.docker.io/token?service=registry.docker.io&scope=repository:%s:pull"
+	dockerhubAuthRealm   = "https://auth.docker.io/token"
+	dockerhubAuthService = "registry.docker.io"
 
 	tagsURLTemplate = "/v2/%s/tags/list?%s"
 
 	gcrTokenEnv = "GOOGLE_ACCESS_TOKEN"
+
+	authenticateHeader = "WWW-Authenticate"
+
+	oauthClientID = "gofer"
 )
 
 type Client interface {
-	AuthHeader(image string) (string, error)
+	AuthHeader(ctx context.Context, image string) (string, error)
 	TagsURL(image, pagination string) string
+	// URL returns the URL of a path in the registry API, ie. '/v2/library/alpine/manifests/3.8'
+	URL(path string) string
 	HTTPClient() *http.Client
 }
 
@@ -34,42 +45,50 @@ func (c *basicHTTPClient) HTTPClient() *http.Client {
 	return c.client
 }
 
+func (c *basicHTTPClient) URL(path string) string {
+	return c.baseURL + path
+}
+
 func (c *basicHTTPClient) TagsURL(image, pagination string) string {
 	return fmt.Sprintf(c.baseURL+tagsURLTemplate, image, pagination)
 }
 
 type dockerhubClient struct {
 	basicHTTPClient
+	credentials CredentialsProvider
 }
 
-type githubAuthResponse struct {
-	Token string
-}
-
-func (c *dockerhubClient) AuthHeader(image string) (string, error) {
-	// get authorization token
-	authURL := fmt.Sprintf(dockerhubAuthURL, image)
-	resp, err := c.client.Get(authURL)
+func (c *dockerhubClient) AuthHeader(ctx context.Context, image string) (string, error) {
+	username, password, err := c.credentials(ctx, dockerhubHostname)
 	if err != nil {
-		return "", fmt.Errorf("could not get authorization token: %v", err)
+		return "", fmt.Errorf("could not get credentials for %q: %v", dockerhubHostname, err)
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-
-	var unmarshaledAuthResp githubAuthResponse
-	if err := json.Unmarshal(body, &unmarshaledAuthResp); err != nil {
-		return "", fmt.Errorf("could not unmarshal authorization token: %v", err)
+	// get authorization token, anonymous when there are no credentials
+	params := map[string]string{"realm": dockerhubAuthRealm, "service": dockerhubAuthService}
+	token, err := fetchToken(ctx, c.client, params, image, username, password)
+	if err != nil {
+		return "", err
 	}
-	return fmt.Sprintf("Bearer %s", unmarshaledAuthResp.Token), nil
+	return fmt.Sprintf("Bearer %s", token), nil
 }
 
 type gcrClient struct {
 	basicHTTPClient
-	tokenProvider func() (string, error)
+	credentials   CredentialsProvider
+	tokenProvider func(ctx context.Context) (string, error)
 }
 
-func (c gcrClient) AuthHeader(_ string) (string, error) {
-	token, err := c.tokenProvider()
+func (c gcrClient) AuthHeader(ctx context.Context, _ string) (string, error) {
+	// prefer credentials from the docker config, ie. the 'gcloud' credential helper
+	username, password, err := c.credentials(ctx, gcrHostname)
+	if err != nil {
+		return "", fmt.Errorf("could not get credentials for %q: %v", gcrHostname, err)
+	}
+	if username != "" && username != identityTokenUsername {
+		return basicAuthHeader(username, password), nil
+	}
+
+	token, err := c.tokenProvider(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -77,10 +96,10 @@ func (c gcrClient) AuthHeader(_ string) (string, error) {
 	return fmt.Sprintf("Basic %s", encoded), nil
 }
 
-func envOrgcloudTokenProvider() (string, error) {
+func envOrgcloudTokenProvider(ctx context.Context) (string, error) {
 	token := os.Getenv(gcrTokenEnv)
 	if token == "" {
-		tokenBytes, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+		tokenBytes, err := exec.CommandContext(ctx, "gcloud", "auth", "print-access-token").Output()
 		if err != nil {
 			return "", fmt.Errorf("error running %q: %v and %q is not set", "gcloud auth print-access-token", err, gcrTokenEnv)
 		}
@@ -90,9 +109,172 @@ func envOrgcloudTokenProvider() (string, error) {
 }
 
 type quayioClient struct {
+	genericClient
+}
+
+func (c *quayioClient) AuthHeader(ctx context.Context, image string) (string, error) {
+	username, _, err := c.credentials(ctx, quayioHostname)
+	if err != nil {
+		return "", fmt.Errorf("could not get credentials for %q: %v", quayioHostname, err)
+	}
+	// public images don't need a token
+	if username == "" {
+		return "", nil
+	}
+	return c.genericClient.AuthHeader(ctx, image)
+}
+
+// CredentialsProvider returns the username and password to use for a registry hostname
+// Empty values mean the registry will be accessed anonymously
+type CredentialsProvider func(ctx context.Context, hostname string) (username, password string, err error)
+
+func anonymousCredentials(_ context.Context, _ string) (string, string, error) {
+	return "", "", nil
+}
+
+// genericClient works with any registry that implements the OCI Distribution API
+// the auth scheme is discovered from the 'WWW-Authenticate' challenge returned by the registry
+type genericClient struct {
 	basicHTTPClient
+	hostname    string
+	credentials CredentialsProvider
+
+	// cache the header, it does not change between paginated requests
+	header string
+	cached bool
+}
+
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+func (c *genericClient) AuthHeader(ctx context.Context, image string) (string, error) {
+	if c.cached {
+		return c.header, nil
+	}
+	req, err := http.NewRequest("GET", c.baseURL+"/v2/", nil)
+	if err != nil {
+		return "", fmt.Errorf("could not get request for %q: %v", c.hostname, err)
+	}
+	// the probe decides how to authenticate, it must always reach the registry
+	resp, err := c.client.Do(req.WithContext(transport.WithoutCache(ctx)))
+	if err != nil {
+		return "", fmt.Errorf("could not reach registry %q: %v", c.hostname, err)
+	}
+	resp.Body.Close()
+
+	header := ""
+	// registry allows anonymous access
+	if resp.StatusCode == http.StatusUnauthorized {
+		header, err = c.challengeHeader(ctx, image, resp.Header.Get(authenticateHeader))
+		if err != nil {
+			return "", err
+		}
+	}
+	c.header = header
+	c.cached = true
+	return header, nil
+}
+
+func (c *genericClient) challengeHeader(ctx context.Context, image, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	username, password, err := c.credentials(ctx, c.hostname)
+	if err != nil {
+		return "", fmt.Errorf("could not get credentials for %q: %v", c.hostname, err)
+	}
+	switch scheme {
+	case "basic":
+		if username == "" || username == identityTokenUsername {
+			return "", fmt.Errorf("registry %q requires basic auth but no credentials were found", c.hostname)
+		}
+		return basicAuthHeader(username, password), nil
+	case "bearer":
+		token, err := fetchToken(ctx, c.client, params, image, username, password)
+		if err != nil {
+			return "", fmt.Errorf("registry %q: %v", c.hostname, err)
+		}
+		return fmt.Sprintf("Bearer %s", token), nil
+	default:
+		return "", fmt.Errorf("unsupported auth challenge %q from registry %q", challenge, c.hostname)
+	}
+}
+
+// fetchToken requests a bearer token from the realm in the auth challenge
+// basic auth is used when there are credentials, identity tokens are exchanged with an OAuth2 refresh token grant
+func fetchToken(ctx context.Context, client *http.Client, params map[string]string, image, username, password string) (string, error) {
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("auth challenge is missing a realm")
+	}
+	authURL, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("could not parse realm %q: %v", realm, err)
+	}
+	service := params["service"]
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", image)
+	}
+
+	var req *http.Request
+	if username == identityTokenUsername {
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", password)
+		form.Set("service", service)
+		form.Set("scope", scope)
+		form.Set("client_id", oauthClientID)
+		req, err = http.NewRequest("POST", authURL.String(), strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", fmt.Errorf("could not get request for %q: %v", authURL, err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		query := authURL.Query()
+		if service != "" {
+			query.Set("service", service)
+		}
+		query.Set("scope", scope)
+		authURL.RawQuery = query.Encode()
+		req, err = http.NewRequest("GET", authURL.String(), nil)
+		if err != nil {
+			return "", fmt.Errorf("could not get request for %q: %v", authURL, err)
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+	}
+
+	// tokens expire, never serve them from the cache
+	resp, err := client.Do(req.WithContext(transport.WithoutCache(ctx)))
+	if err != nil {
+		return "", fmt.Errorf("could not get authorization token: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("got a bad return code %d getting authorization token from %q", resp.StatusCode, realm)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not read authorization token: %v", err)
+	}
+
+	var unmarshaledTokenResp tokenResponse
+	if err := json.Unmarshal(body, &unmarshaledTokenResp); err != nil {
+		return "", fmt.Errorf("could not unmarshal authorization token: %v", err)
+	}
+	// 'access_token' is the OAuth2 compatible field, 'token' is the older one
+	if unmarshaledTokenResp.Token != "" {
+		return unmarshaledTokenResp.Token, nil
+	}
+	if unmarshaledTokenResp.AccessToken != "" {
+		return unmarshaledTokenResp.AccessToken, nil
+	}
+	return "", fmt.Errorf("empty authorization token returned from %q", realm)
 }
 
-func (c quayioClient) AuthHeader(_ string) (string, error) {
-	return "", nil
+func basicAuthHeader(username, password string) string {
+	encoded := b64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return fmt.Sprintf("Basic %s", encoded)
 }
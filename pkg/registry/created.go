@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	manifestURLTemplate = "/v2/%s/manifests/%s"
+	blobURLTemplate     = "/v2/%s/blobs/%s"
+
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+
+	defaultOS           = "linux"
+	defaultArchitecture = "amd64"
+)
+
+var manifestMediaTypes = []string{mediaTypeDockerManifest, mediaTypeDockerManifestList, mediaTypeOCIManifest, mediaTypeOCIIndex}
+
+// manifest is either an image manifest or a list of manifests for multiple platforms
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+type imageConfig struct {
+	Created time.Time `json:"created"`
+}
+
+func getCreated(ctx context.Context, image, tag string, client Client) (time.Time, error) {
+	m, err := getManifest(ctx, image, tag, client)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// use the image of a single platform from a multi-platform list
+	if len(m.Manifests) > 0 {
+		digest := m.Manifests[0].Digest
+		for _, platform := range m.Manifests {
+			if platform.Platform.OS == defaultOS && platform.Platform.Architecture == defaultArchitecture {
+				digest = platform.Digest
+				break
+			}
+		}
+		if m, err = getManifest(ctx, image, digest, client); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if m.Config.Digest == "" {
+		return time.Time{}, fmt.Errorf("manifest for %s:%s does not have a config", image, tag)
+	}
+
+	var config imageConfig
+	if err := get(ctx, image, client.URL(fmt.Sprintf(blobURLTemplate, image, m.Config.Digest)), "", client, &config); err != nil {
+		return time.Time{}, fmt.Errorf("could not get config for %s:%s: %v", image, tag, err)
+	}
+	if config.Created.IsZero() {
+		return time.Time{}, fmt.Errorf("config for %s:%s does not have a created timestamp", image, tag)
+	}
+	return config.Created, nil
+}
+
+func getManifest(ctx context.Context, image, reference string, client Client) (*manifest, error) {
+	m := &manifest{}
+	url := client.URL(fmt.Sprintf(manifestURLTemplate, image, reference))
+	if err := get(ctx, image, url, strings.Join(manifestMediaTypes, ", "), client, m); err != nil {
+		return nil, fmt.Errorf("could not get manifest for %s:%s: %v", image, reference, err)
+	}
+	return m, nil
+}
+
+// get unmarshals the JSON response of an authorized request
+func get(ctx context.Context, image, url, accept string, client Client, out interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("could not get request for %q: %v", url, err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	header, err := client.AuthHeader(ctx, image)
+	if err != nil {
+		return fmt.Errorf("could not get auth header for %q: %v", image, err)
+	}
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+
+	resp, err := client.HTTPClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got a bad return code %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response: %v", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("could not unmarshal response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetCreated(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/alpine/manifests/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "TOKEN" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Accept"), mediaTypeOCIIndex) {
+			t.Errorf("expected the request to accept %q, instead got %q", mediaTypeOCIIndex, r.Header.Get("Accept"))
+		}
+		switch strings.TrimPrefix(r.URL.Path, "/v2/alpine/manifests/") {
+		case "3.8":
+			fmt.Fprintf(w, `{"mediaType": %q, "config": {"digest": "sha256:config38"}}`, mediaTypeDockerManifest)
+		case "3.9":
+			fmt.Fprintf(w, `{"mediaType": %q, "manifests": [
+				{"digest": "sha256:arm64", "platform": {"architecture": "arm64", "os": "linux"}},
+				{"digest": "sha256:amd64", "platform": {"architecture": "amd64", "os": "linux"}}
+			]}`, mediaTypeOCIIndex)
+		case "sha256:amd64":
+			fmt.Fprintf(w, `{"mediaType": %q, "config": {"digest": "sha256:config39"}}`, mediaTypeOCIManifest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("/v2/alpine/blobs/sha256:config38", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"architecture": "amd64", "created": "2018-06-27T20:10:20.123Z"}`)
+	})
+	mux.HandleFunc("/v2/alpine/blobs/sha256:config39", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"architecture": "amd64", "created": "2019-01-30T22:19:52.000Z"}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		tag         string
+		expected    time.Time
+		expectedErr string
+	}{
+		{tag: "3.8", expected: time.Date(2018, 6, 27, 20, 10, 20, 123000000, time.UTC)},
+		{tag: "3.9", expected: time.Date(2019, 1, 30, 22, 19, 52, 0, time.UTC)},
+		{tag: "3.10", expectedErr: "got a bad return code 404"},
+	}
+	c := mockClient{basicHTTPClient{client: ts.Client(), baseURL: ts.URL}}
+	for _, test := range tests {
+		created, err := getCreated(context.Background(), "alpine", test.tag, &c)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("%s: expected an error to contain %q, instead got: %v", test.tag, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.tag, err)
+			continue
+		}
+		if !created.Equal(test.expected) {
+			t.Errorf("%s: expected created to be %s, instead got %s", test.tag, test.expected, created)
+		}
+	}
+}
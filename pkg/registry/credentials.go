@@ -0,0 +1,203 @@
+package registry
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	dockerConfigEnv  = "DOCKER_CONFIG"
+	dockerConfigDir  = ".docker"
+	dockerConfigFile = "config.json"
+
+	// docker CLI stores Docker Hub credentials under this key
+	dockerhubConfigKey = "https://index.docker.io/v1/"
+
+	credentialHelperPrefix = "docker-credential-"
+	// returned by the credential helpers when the server is not in the store
+	credentialsNotFound = "credentials not found"
+
+	// username used by the docker CLI when the password is an identity (refresh) token
+	identityTokenUsername = "<token>"
+)
+
+type dockerConfig struct {
+	Auths       map[string]dockerAuthConfig `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+type dockerAuthConfig struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+type credentialHelperResponse struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// runCredentialHelper executes 'docker-credential-<helper> get', set here for simpler testing
+// The helper is killed when the context is done, ie. on Ctrl-C or when the dependency times out
+var runCredentialHelper = func(ctx context.Context, helper, serverURL string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, credentialHelperPrefix+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	return cmd.Output()
+}
+
+type resolvedCredentials struct {
+	username string
+	password string
+}
+
+// DockerConfigCredentials resolves credentials the same way the docker CLI does
+// 'credHelpers' take precedence over 'credsStore' which takes precedence over the 'auths' entries
+// The config is read from $DOCKER_CONFIG/config.json or ~/.docker/config.json
+// The credentials of each registry are resolved once, so a credential helper only runs once per registry
+func DockerConfigCredentials() CredentialsProvider {
+	var mu sync.Mutex
+	resolved := make(map[string]resolvedCredentials)
+	return func(ctx context.Context, hostname string) (string, string, error) {
+		hostname = normalizeRegistryHostname(hostname)
+		mu.Lock()
+		defer mu.Unlock()
+		if c, ok := resolved[hostname]; ok {
+			return c.username, c.password, nil
+		}
+		config, err := readDockerConfig(dockerConfigPath())
+		if err != nil {
+			return "", "", err
+		}
+		username, password, err := config.credentials(ctx, hostname)
+		if err != nil {
+			return "", "", err
+		}
+		resolved[hostname] = resolvedCredentials{username: username, password: password}
+		return username, password, nil
+	}
+}
+
+func dockerConfigPath() string {
+	dir := os.Getenv(dockerConfigEnv)
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, dockerConfigDir)
+	}
+	return filepath.Join(dir, dockerConfigFile)
+}
+
+func readDockerConfig(path string) (*dockerConfig, error) {
+	config := &dockerConfig{}
+	if path == "" {
+		return config, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		// not having a config file is not an error, access registries anonymously
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, fmt.Errorf("could not read docker config file %q: %v", path, err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("could not unmarshal docker config file %q: %v", path, err)
+	}
+	return config, nil
+}
+
+func (c dockerConfig) credentials(ctx context.Context, hostname string) (string, string, error) {
+	hostname = normalizeRegistryHostname(hostname)
+	if helper, ok := c.CredHelpers[hostname]; ok && helper != "" {
+		return credentialsFromHelper(ctx, helper, configKey(hostname))
+	}
+	if c.CredsStore != "" {
+		username, password, err := credentialsFromHelper(ctx, c.CredsStore, configKey(hostname))
+		if err != nil || username != "" || password != "" {
+			return username, password, err
+		}
+	}
+
+	for key, auth := range c.Auths {
+		if normalizeRegistryHostname(key) != hostname {
+			continue
+		}
+		return auth.decode()
+	}
+	return "", "", nil
+}
+
+func (a dockerAuthConfig) decode() (string, string, error) {
+	if a.IdentityToken != "" {
+		return identityTokenUsername, a.IdentityToken, nil
+	}
+	if a.Auth == "" {
+		return a.Username, a.Password, nil
+	}
+	decoded, err := b64.StdEncoding.DecodeString(a.Auth)
+	if err != nil {
+		return "", "", fmt.Errorf("could not decode auth in docker config: %v", err)
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid auth in docker config, expected 'username:password'")
+	}
+	return parts[0], parts[1], nil
+}
+
+func credentialsFromHelper(ctx context.Context, helper, serverURL string) (string, string, error) {
+	out, err := runCredentialHelper(ctx, helper, serverURL)
+	if err != nil {
+		if strings.Contains(string(out), credentialsNotFound) {
+			return "", "", nil
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok && strings.Contains(string(exitErr.Stderr), credentialsNotFound) {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("error running credential helper %q: %v", credentialHelperPrefix+helper, err)
+	}
+
+	var resp credentialHelperResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return "", "", fmt.Errorf("could not unmarshal credential helper %q response: %v", credentialHelperPrefix+helper, err)
+	}
+	return resp.Username, resp.Secret, nil
+}
+
+// normalizeRegistryHostname strips the scheme and path from a docker config key
+// all of the Docker Hub hostnames are treated as 'docker.io'
+func normalizeRegistryHostname(key string) string {
+	hostname := key
+	if i := strings.Index(hostname, "://"); i != -1 {
+		hostname = hostname[i+3:]
+	}
+	if i := strings.Index(hostname, "/"); i != -1 {
+		hostname = hostname[:i]
+	}
+	switch hostname {
+	case dockerhubHostname, "index.docker.io", dockerhubAPIURL:
+		return dockerhubHostname
+	}
+	return hostname
+}
+
+// configKey returns the server URL the docker CLI uses when storing credentials for a hostname
+func configKey(hostname string) string {
+	if hostname == dockerhubHostname {
+		return dockerhubConfigKey
+	}
+	return hostname
+}
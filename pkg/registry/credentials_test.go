@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const testDockerConfig = `{
+	"auths": {
+		"https://index.docker.io/v1/": {
+			"auth": "aHViLXVzZXI6aHViLXBhc3N3b3Jk"
+		},
+		"harbor.example.com": {
+			"username": "harbor-user",
+			"password": "harbor-password"
+		},
+		"https://registry.example.com/v2/": {
+			"identitytoken": "refresh-token"
+		},
+		"gcr.io": {},
+		"quay.io": {}
+	},
+	"credsStore": "desktop",
+	"credHelpers": {
+		"gcr.io": "gcloud"
+	}
+}`
+
+func TestDockerConfigCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "goferdockerconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, dockerConfigFile), []byte(testDockerConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv(dockerConfigEnv, os.Getenv(dockerConfigEnv))
+	os.Setenv(dockerConfigEnv, dir)
+
+	defer func(run func(ctx context.Context, helper, serverURL string) ([]byte, error)) {
+		runCredentialHelper = run
+	}(runCredentialHelper)
+	runs := make(map[string]int)
+	runCredentialHelper = func(_ context.Context, helper, serverURL string) ([]byte, error) {
+		runs[serverURL]++
+		switch {
+		case helper == "gcloud" && serverURL == "gcr.io":
+			return []byte(`{"ServerURL": "gcr.io", "Username": "_dcgcloud_token", "Secret": "gcloud-token"}`), nil
+		case helper == "desktop" && serverURL == "quay.io":
+			return []byte(`{"ServerURL": "quay.io", "Username": "quay-user", "Secret": "quay-password"}`), nil
+		case helper == "desktop":
+			return []byte("credentials not found in native keychain\n"), fmt.Errorf("exit status 1")
+		}
+		return nil, fmt.Errorf("unexpected helper %q", helper)
+	}
+
+	tests := []struct {
+		hostname         string
+		expectedUsername string
+		expectedPassword string
+	}{
+		{hostname: "docker.io", expectedUsername: "hub-user", expectedPassword: "hub-password"},
+		{hostname: "harbor.example.com", expectedUsername: "harbor-user", expectedPassword: "harbor-password"},
+		{hostname: "registry.example.com", expectedUsername: identityTokenUsername, expectedPassword: "refresh-token"},
+		{hostname: "gcr.io", expectedUsername: "_dcgcloud_token", expectedPassword: "gcloud-token"},
+		{hostname: "quay.io", expectedUsername: "quay-user", expectedPassword: "quay-password"},
+		{hostname: "localhost:5000"},
+	}
+
+	credentials := DockerConfigCredentials()
+	// the helpers only run the first time the credentials of a registry are resolved
+	for i := 0; i < 2; i++ {
+		for _, test := range tests {
+			username, password, err := credentials(context.Background(), test.hostname)
+			if err != nil {
+				t.Errorf("unexpected error getting credentials for %q: %v", test.hostname, err)
+				continue
+			}
+			if username != test.expectedUsername || password != test.expectedPassword {
+				t.Errorf("expected credentials for %q to be %q:%q, instead got %q:%q", test.hostname, test.expectedUsername, test.expectedPassword, username, password)
+			}
+		}
+	}
+	for serverURL, n := range runs {
+		if n != 1 {
+			t.Errorf("expected the credential helper to run once for %q, instead it ran %d times", serverURL, n)
+		}
+	}
+}
+
+func TestCredentialHelperCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test helper is a shell script")
+	}
+	dir, err := ioutil.TempDir(os.TempDir(), "gofercredentialhelper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, credentialHelperPrefix+"hung"), []byte("#!/bin/sh\nexec sleep 60\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, err := credentialsFromHelper(ctx, "hung", "registry.example.com"); err == nil {
+		t.Errorf("expected an error for a helper that was killed")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the helper to be killed when the context is done, instead it ran for %s", elapsed)
+	}
+}
+
+func TestDockerConfigCredentialsMissingFile(t *testing.T) {
+	defer os.Setenv(dockerConfigEnv, os.Getenv(dockerConfigEnv))
+	os.Setenv(dockerConfigEnv, filepath.Join(os.TempDir(), "goferdockerconfigmissing"))
+
+	username, password, err := DockerConfigCredentials()(context.Background(), "docker.io")
+	if err != nil {
+		t.Fatalf("unexpected error getting credentials: %v", err)
+	}
+	if username != "" || password != "" {
+		t.Errorf("expected empty credentials, instead got %q:%q", username, password)
+	}
+}
@@ -1,12 +1,16 @@
 package registry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strings"
+	"time"
 
+	"github.com/dkoshkin/gofer/pkg/transport"
 	parser "github.com/novln/docker-parser"
 )
 
@@ -17,10 +21,15 @@ const (
 	quayioHostname    = "quay.io"
 
 	paginationHeader = "link"
+
+	// safety net for requests made without a deadline, contexts should be used for shorter timeouts
+	defaultTimeout = 5 * time.Minute
 )
 
 type Registry struct {
 	Client *http.Client
+	// Credentials are resolved per registry hostname
+	Credentials CredentialsProvider
 	// set baseURLs here for simpler testing
 	dockerhuBaseURL string
 	gcrBaseURL      string
@@ -33,7 +42,8 @@ type tagsResponse struct {
 
 func New() *Registry {
 	return &Registry{
-		Client:          &http.Client{},
+		Client:          &http.Client{Timeout: defaultTimeout, Transport: transport.New()},
+		Credentials:     DockerConfigCredentials(),
 		dockerhuBaseURL: fmt.Sprintf("https://%s", dockerhubAPIURL),
 		gcrBaseURL:      fmt.Sprintf("https://%s", gcrHostname),
 		quayioBaseURL:   fmt.Sprintf("https://%s", quayioHostname),
@@ -41,31 +51,62 @@ func New() *Registry {
 }
 
 // Tags return all tags for an image
-func (r Registry) Tags(image string) ([]string, error) {
+func (r Registry) Tags(ctx context.Context, image string) ([]string, error) {
+	ctx, client, name, err := r.client(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	return getTags(ctx, name, client)
+}
+
+// Created returns when the image for a tag was built, from the 'created' timestamp of its config
+// The linux/amd64 image is used for multi-platform images
+func (r Registry) Created(ctx context.Context, image, tag string) (time.Time, error) {
+	ctx, client, name, err := r.client(ctx, image)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return getCreated(ctx, name, tag, client)
+}
+
+// client returns the client for the image's registry and the name of the image in the registry
+// The context keys the cached responses by the configured credentials, the auth headers have short-lived tokens
+func (r Registry) client(ctx context.Context, image string) (context.Context, Client, string, error) {
 	parsed, err := parser.Parse(image)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse image %q: %v", image, err)
+		return nil, nil, "", fmt.Errorf("could not parse image %q: %v", image, err)
+	}
+	credentials := r.Credentials
+	if credentials == nil {
+		credentials = anonymousCredentials
 	}
+	regsitry := parsed.Registry()
+	username, password, err := credentials(ctx, regsitry)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("could not get credentials for %q: %v", regsitry, err)
+	}
+	ctx = transport.WithCredentials(ctx, regsitry, username, password)
+
 	var client Client
 	httpClient := basicHTTPClient{client: r.Client}
-	switch regsitry := parsed.Registry(); regsitry {
+	switch regsitry {
 	case dockerhubHostname:
 		httpClient.baseURL = r.dockerhuBaseURL
-		client = &dockerhubClient{httpClient}
+		client = &dockerhubClient{httpClient, credentials}
 	case gcrHostname:
 		httpClient.baseURL = r.gcrBaseURL
-		client = &gcrClient{httpClient, envOrgcloudTokenProvider}
+		client = &gcrClient{httpClient, credentials, envOrgcloudTokenProvider}
 	case quayioHostname:
 		httpClient.baseURL = r.quayioBaseURL
-		client = &quayioClient{httpClient}
+		client = &quayioClient{genericClient{basicHTTPClient: httpClient, hostname: regsitry, credentials: credentials}}
 	default:
-		return nil, fmt.Errorf("unsupported registry %q", regsitry)
+		httpClient.baseURL = genericBaseURL(regsitry)
+		client = &genericClient{basicHTTPClient: httpClient, hostname: regsitry, credentials: credentials}
 	}
-
-	return getTags(parsed.ShortName(), client)
+	return ctx, client, parsed.ShortName(), nil
 }
 
-func getTags(image string, client Client) ([]string, error) {
+func getTags(ctx context.Context, image string, client Client) ([]string, error) {
 	tags := []string{}
 	var paginationParam string
 	// use a loop incase results are paginated
@@ -74,17 +115,20 @@ func getTags(image string, client Client) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("could not get request for %q: %v", image, err)
 		}
-		header, err := client.AuthHeader(image)
+		header, err := client.AuthHeader(ctx, image)
 		if err != nil {
 			return nil, fmt.Errorf("could not get auth header for %q: %v", image, err)
 		}
-		req.Header.Add("Authorization", header)
+		if header != "" {
+			req.Header.Add("Authorization", header)
+		}
 
-		resp, err := client.HTTPClient().Do(req)
+		resp, err := client.HTTPClient().Do(req.WithContext(ctx))
 		if err != nil {
 			return nil, fmt.Errorf("could not get tags for image %q: %v", image, err)
 		}
 		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
 			return nil, fmt.Errorf("got a bad return code %d for image %q", resp.StatusCode, image)
 		}
 
@@ -109,3 +153,16 @@ func getTags(image string, client Client) ([]string, error) {
 	}
 	return tags, nil
 }
+
+// genericBaseURL returns the API URL for a registry hostname
+// local registries, ie. 'registry:2' running on localhost, are usually served over plain HTTP
+func genericBaseURL(hostname string) string {
+	host := hostname
+	if i := strings.LastIndex(host, ":"); i != -1 {
+		host = host[:i]
+	}
+	if host == "localhost" || host == "127.0.0.1" {
+		return fmt.Sprintf("http://%s", hostname)
+	}
+	return fmt.Sprintf("https://%s", hostname)
+}
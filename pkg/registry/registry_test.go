@@ -1,18 +1,30 @@
 package registry
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/dkoshkin/gofer/pkg/transport"
 )
 
+func TestMain(m *testing.M) {
+	// don't cache the responses of the mock registries
+	transport.DefaultCache.Dir = ""
+	os.Exit(m.Run())
+}
+
 type mockClient struct {
 	basicHTTPClient
 }
 
-func (c *mockClient) AuthHeader(image string) (string, error) {
+func (c *mockClient) AuthHeader(_ context.Context, image string) (string, error) {
 	return "TOKEN", nil
 }
 
@@ -36,7 +48,7 @@ func TestGetTags(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		tags, err := getTags(test.image, &c)
+		tags, err := getTags(context.Background(), test.image, &c)
 		if test.notFound {
 			if err == nil || !strings.Contains(err.Error(), "got a bad return code") {
 				t.Errorf("expected an error to contain 'got a bad return code' instead got: %v", err)
@@ -66,6 +78,150 @@ func mockServer() *httptest.Server {
 	return httptest.NewTLSServer(mux)
 }
 
+func TestGetTagsTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	c := mockClient{basicHTTPClient{client: ts.Client(), baseURL: ts.URL}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := getTags(ctx, "alpine", &c)
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("expected a deadline exceeded error, instead got: %v", err)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	tests := []struct {
+		header         string
+		expectedScheme string
+		expectedParams map[string]string
+	}{
+		{
+			header:         `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"`,
+			expectedScheme: "bearer",
+			expectedParams: map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io", "scope": "repository:library/alpine:pull"},
+		},
+		{
+			header:         `Bearer realm="https://harbor.example.com/service/token", service="harbor-registry", scope="repository:a/b:pull,push"`,
+			expectedScheme: "bearer",
+			expectedParams: map[string]string{"realm": "https://harbor.example.com/service/token", "service": "harbor-registry", "scope": "repository:a/b:pull,push"},
+		},
+		{
+			header:         `Basic realm="Registry Realm"`,
+			expectedScheme: "basic",
+			expectedParams: map[string]string{"realm": "Registry Realm"},
+		},
+		{
+			header:         "",
+			expectedScheme: "",
+			expectedParams: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		scheme, params := parseChallenge(test.header)
+		if scheme != test.expectedScheme {
+			t.Errorf("expected scheme %q, instead got %q", test.expectedScheme, scheme)
+		}
+		if !reflect.DeepEqual(params, test.expectedParams) {
+			t.Errorf("expected params %v, instead got %v", test.expectedParams, params)
+		}
+	}
+}
+
+func TestGenericRegistryTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		challenge   string
+		username    string
+		password    string
+		expectedErr string
+	}{
+		{name: "anonymous"},
+		{name: "anonymous bearer token", challenge: "bearer"},
+		{name: "bearer token with credentials", challenge: "bearer", username: "user", password: "secret"},
+		{name: "basic auth", challenge: "basic", username: "user", password: "secret"},
+		{name: "basic auth without credentials", challenge: "basic", expectedErr: "requires basic auth but no credentials were found"},
+	}
+
+	for _, test := range tests {
+		ts := mockGenericServer(t, test.challenge, test.username, test.password)
+		r := New()
+		r.Credentials = func(_ context.Context, hostname string) (string, string, error) {
+			return test.username, test.password, nil
+		}
+		hostname := strings.TrimPrefix(ts.URL, "http://")
+		tags, err := r.Tags(context.Background(), fmt.Sprintf("%s/library/alpine", hostname))
+		ts.Close()
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("%s: expected an error to contain %q instead got: %v", test.name, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error getting tags: %v", test.name, err)
+			continue
+		}
+		if len(tags) != 12 {
+			t.Errorf("%s: unexpected number of tags returned %d", test.name, len(tags))
+		}
+	}
+}
+
+func mockGenericServer(t *testing.T, challenge, username, password string) *httptest.Server {
+	const token = "TOKEN"
+	var ts *httptest.Server
+	authorized := func(r *http.Request) bool {
+		switch challenge {
+		case "bearer":
+			return r.Header.Get("Authorization") == "Bearer "+token
+		case "basic":
+			u, p, ok := r.BasicAuth()
+			return ok && u == username && p == password
+		}
+		return true
+	}
+	unauthorized := func(w http.ResponseWriter) {
+		switch challenge {
+		case "bearer":
+			w.Header().Set(authenticateHeader, fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, ts.URL))
+		case "basic":
+			w.Header().Set(authenticateHeader, `Basic realm="test-registry"`)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			unauthorized(w)
+			return
+		}
+		if r.URL.Path == "/v2/library/alpine/tags/list" {
+			fmt.Fprint(w, dockerhubAlpineResp)
+		}
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("service") != "test-registry" || r.URL.Query().Get("scope") != "repository:library/alpine:pull" {
+			t.Errorf("unexpected token request %q", r.URL.String())
+		}
+		u, p, ok := r.BasicAuth()
+		if username != "" && (!ok || u != username || p != password) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"token": %q}`, token)
+	})
+	ts = httptest.NewServer(mux)
+	return ts
+}
+
 var dockerhubAlpineResp = `{
     "name": "library/alpine",
     "tags": [
@@ -0,0 +1,68 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+)
+
+// imageReference returns a docker reference for an image, ie. "nginx:1.19.2"
+// Images without a tag, with a tag that has no numbers, ie. "latest", or with variables are not versioned and return false
+// Images pinned to a digest, ie. "alpine:3.12@sha256:...", are skipped, the image that is pulled doesn't change with the tag
+func imageReference(image, path string, line int) (Reference, bool) {
+	if image == "" || strings.ContainsAny(image, "${}@") {
+		return Reference{}, false
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return Reference{}, false
+	}
+	name, tag := image[:i], image[i+1:]
+	if name == "" || !digits.MatchString(tag) {
+		return Reference{}, false
+	}
+	return Reference{Type: dependency.DockerType, Name: name, Version: tag, File: path, Line: line}, true
+}
+
+func isDockerfile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return name == "dockerfile" || strings.HasPrefix(name, "dockerfile.") || strings.HasSuffix(name, ".dockerfile")
+}
+
+var fromInstruction = regexp.MustCompile(`(?i)^\s*FROM\s+(.*)$`)
+
+// scanDockerfile returns the images in the 'FROM' instructions, previous build stages and 'scratch' are skipped
+func scanDockerfile(path string, data []byte) ([]Reference, error) {
+	refs := make([]Reference, 0)
+	stages := map[string]bool{"scratch": true}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		match := fromInstruction.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		var args []string
+		for _, arg := range strings.Fields(match[1]) {
+			if !strings.HasPrefix(arg, "--") {
+				args = append(args, arg)
+			}
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if len(args) >= 3 && strings.EqualFold(args[1], "as") {
+			stages[strings.ToLower(args[2])] = true
+		}
+		if stages[strings.ToLower(args[0])] {
+			continue
+		}
+		if ref, ok := imageReference(args[0], path, n); ok {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, scanner.Err()
+}
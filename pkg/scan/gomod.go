@@ -0,0 +1,57 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+)
+
+func isGoMod(path string) bool {
+	return filepath.Base(path) == "go.mod"
+}
+
+var (
+	requireRgx      = regexp.MustCompile(`^require\s+(\S+)\s+(\S+)`)
+	requireBlockRgx = regexp.MustCompile(`^require\s*\($`)
+	requirementRgx  = regexp.MustCompile(`^(\S+)\s+(\S+)`)
+)
+
+// scanGoMod returns the modules in the 'require' directives, indirect requirements are skipped
+func scanGoMod(path string, data []byte) ([]Reference, error) {
+	refs := make([]Reference, 0)
+	var inBlock bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, "// indirect") {
+			continue
+		}
+		var match []string
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock:
+			match = requirementRgx.FindStringSubmatch(line)
+		case requireBlockRgx.MatchString(line):
+			inBlock = true
+		default:
+			match = requireRgx.FindStringSubmatch(line)
+		}
+		if match == nil || strings.HasPrefix(match[1], "//") {
+			continue
+		}
+		refs = append(refs, Reference{Type: dependency.GoModType, Name: strings.Trim(match[1], `"`), Version: match[2], File: path, Line: n})
+	}
+	return refs, scanner.Err()
+}
+
+var pseudoVersionRgx = regexp.MustCompile(`[-.](?:0\.)?[0-9]{14}-[0-9a-f]{12}(?:\+incompatible)?$`)
+
+// isPseudoVersion returns true for versions of untagged commits, ie. 'v0.0.0-20200107190931-bf48bf16ab8d'
+func isPseudoVersion(version string) bool {
+	return pseudoVersionRgx.MatchString(version)
+}
@@ -0,0 +1,188 @@
+package scan
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dkoshkin/gofer/pkg/dependency"
+	"github.com/dkoshkin/gofer/pkg/fetcher"
+)
+
+// Reference is a versioned dependency found in a file
+type Reference struct {
+	// Type is the dependency type, ie. "docker"
+	Type    string
+	Name    string
+	Version string
+	// File and Line are where the reference was found
+	File string
+	Line int
+	// YAMLPath is the path to the version when it is a separate YAML value, ie. "image.tag"
+	YAMLPath string
+}
+
+// Spec returns a dependency for the reference with a mask that matches versions with the same format
+func (r Reference) Spec() dependency.Spec {
+	spec := dependency.Spec{
+		Name:      r.Name,
+		Type:      r.Type,
+		Version:   r.Version,
+		Mask:      InferMask(r.Version),
+		Locations: []dependency.Location{r.Location()},
+	}
+	if r.Type == dependency.GoModType {
+		// a new major version changes the module path, so it can't be written to the 'require' line
+		spec.UpdatePolicy = fetcher.UpdatePolicyMinor
+		// the commit hash of a pseudo-version doesn't describe the format
+		if isPseudoVersion(r.Version) {
+			spec.Mask = ""
+		}
+	}
+	return spec
+}
+
+// Location returns where to write a new version of the reference
+// A version that is not a separate YAML value is matched by the name of the reference and a version with the same format
+// An image followed by a digest is never matched, writing the tag would leave the old digest in place
+// A module is only matched in a 'require' directive or block, the same module may be in a 'replace' directive
+func (r Reference) Location() dependency.Location {
+	if r.YAMLPath != "" {
+		return dependency.Location{File: r.File, YAMLPath: r.YAMLPath}
+	}
+	if r.Type == dependency.GoModType {
+		return dependency.Location{File: r.File, Regex: fmt.Sprintf(`(?m)^require\s+(?:\([^)]*?\n\s*)?%s\s+(v\S+)`, regexp.QuoteMeta(r.Name))}
+	}
+	regex := fmt.Sprintf(`(?m)(?:^|[\s"'=])%s:(%s)(?:$|[\s"'])`, regexp.QuoteMeta(r.Name), InferMask(r.Version))
+	return dependency.Location{File: r.File, Regex: regex}
+}
+
+// scanner finds the references in the contents of the files it matches
+type scanner struct {
+	match func(path string) bool
+	scan  func(path string, data []byte) ([]Reference, error)
+}
+
+var scanners = []scanner{
+	{match: isDockerfile, scan: scanDockerfile},
+	{match: isYAML, scan: scanYAML},
+	{match: isGoMod, scan: scanGoMod},
+}
+
+// skippedDirs are never walked into when scanning a directory
+var skippedDirs = []string{".git", "vendor", "node_modules"}
+
+// Paths returns the references in the files, directories are scanned recursively
+// Files that can't be parsed are skipped when they are found in a directory, ie. Helm templates
+func Paths(paths ...string) ([]Reference, error) {
+	refs := make([]Reference, 0)
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			found, err := scanFile(root)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, found...)
+			continue
+		}
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path != root && stringInSlice(info.Name(), skippedDirs) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			found, err := scanFile(path)
+			if err != nil {
+				return nil
+			}
+			refs = append(refs, found...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return refs, nil
+}
+
+func scanFile(path string) ([]Reference, error) {
+	for _, s := range scanners {
+		if !s.match(path) {
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := s.scan(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan %q: %v", path, err)
+		}
+		return refs, nil
+	}
+	return nil, nil
+}
+
+// Specs returns a dependency for each name with the locations of all of its references
+// The first version found is used when a name is referenced with different versions
+func Specs(refs []Reference) []dependency.Spec {
+	specs := make([]dependency.Spec, 0, len(refs))
+	index := make(map[string]int)
+	for _, ref := range refs {
+		i, ok := index[ref.Name]
+		if !ok {
+			index[ref.Name] = len(specs)
+			specs = append(specs, ref.Spec())
+			continue
+		}
+		if location := ref.Location(); !hasLocation(specs[i].Locations, location) {
+			specs[i].Locations = append(specs[i].Locations, location)
+		}
+	}
+	return specs
+}
+
+func hasLocation(locations []dependency.Location, location dependency.Location) bool {
+	for _, l := range locations {
+		if l == location {
+			return true
+		}
+	}
+	return false
+}
+
+var digits = regexp.MustCompile(`[0-9]+`)
+
+// InferMask returns a regex that matches versions with the same format as the version
+// Numbers match any number and everything else must be the same, ie. "1.19.2-alpine" returns "[0-9]+\.[0-9]+\.[0-9]+-alpine"
+func InferMask(version string) string {
+	var mask strings.Builder
+	last := 0
+	for _, loc := range digits.FindAllStringIndex(version, -1) {
+		mask.WriteString(regexp.QuoteMeta(version[last:loc[0]]))
+		mask.WriteString("[0-9]+")
+		last = loc[1]
+	}
+	mask.WriteString(regexp.QuoteMeta(version[last:]))
+	return mask.String()
+}
+
+func stringInSlice(a string, list []string) bool {
+	for _, b := range list {
+		if b == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package scan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testFiles = map[string]string{
+	"Dockerfile": `ARG GO_VERSION=1.14
+FROM golang:${GO_VERSION} AS build
+FROM --platform=linux/amd64 golang:1.14.4-alpine AS builder
+RUN go build ./...
+FROM builder AS test
+FROM scratch
+FROM gcr.io/distroless/static:nonroot
+from alpine:3.12@sha256:185518070891758909c9f839cf4ca393ee977ac378609f700f60a771a2dfe321
+`,
+	"docker-compose.yml": `version: "3"
+services:
+  db:
+    image: postgres:12.3
+  cache:
+    image: "redis"
+  app:
+    image: ${REGISTRY}/app:1.0.0
+`,
+	"deploy/app.yaml": `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox:1.28.1
+      containers:
+      - name: app
+        image: quay.io/coreos/etcd:v3.4.9
+---
+apiVersion: v1
+kind: Service
+`,
+	"chart/values.yaml": `controller:
+  image:
+    registry: k8s.gcr.io
+    repository: ingress-nginx/controller
+    tag: v0.34.1
+    pullPolicy: IfNotPresent
+`,
+	"chart/templates/deployment.yaml": `spec:
+  containers:
+  {{- range .Values.containers }}
+  - image: {{ .image }}
+  {{- end }}
+`,
+	"go.mod": `module github.com/dkoshkin/gofer
+
+go 1.14
+
+replace (
+	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d => ../oauth2
+	github.com/spf13/cobra v1.0.0 => github.com/example/cobra v1.0.1
+)
+
+require github.com/spf13/cobra v1.0.0
+
+require (
+	cloud.google.com/go/firestore v1.2.0
+	github.com/sendgrid/rest v2.4.1+incompatible // indirect
+	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
+)
+`,
+	"vendor/Dockerfile": `FROM nginx:1.19.2
+`,
+	"README.md": `FROM nginx:1.19.2
+`,
+}
+
+func TestPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gofer-scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range testFiles {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refs, err := Paths(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Reference{
+		{Type: "docker", Name: "golang", Version: "1.14.4-alpine", File: filepath.Join(dir, "Dockerfile"), Line: 3},
+		{Type: "docker", Name: "k8s.gcr.io/ingress-nginx/controller", Version: "v0.34.1", File: filepath.Join(dir, "chart/values.yaml"), Line: 5, YAMLPath: "controller.image.tag"},
+		{Type: "docker", Name: "busybox", Version: "1.28.1", File: filepath.Join(dir, "deploy/app.yaml"), Line: 8},
+		{Type: "docker", Name: "quay.io/coreos/etcd", Version: "v3.4.9", File: filepath.Join(dir, "deploy/app.yaml"), Line: 11},
+		{Type: "docker", Name: "postgres", Version: "12.3", File: filepath.Join(dir, "docker-compose.yml"), Line: 4},
+		{Type: "gomod", Name: "github.com/spf13/cobra", Version: "v1.0.0", File: filepath.Join(dir, "go.mod"), Line: 10},
+		{Type: "gomod", Name: "cloud.google.com/go/firestore", Version: "v1.2.0", File: filepath.Join(dir, "go.mod"), Line: 13},
+		{Type: "gomod", Name: "golang.org/x/oauth2", Version: "v0.0.0-20200107190931-bf48bf16ab8d", File: filepath.Join(dir, "go.mod"), Line: 15},
+	}
+	if !reflect.DeepEqual(refs, expected) {
+		t.Errorf("expected references\n%+v\ninstead got\n%+v", expected, refs)
+	}
+
+	for _, ref := range refs {
+		data, err := ioutil.ReadFile(ref.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rewritten, err := ref.Location().Rewrite(data, "1.2.3")
+		if err != nil {
+			t.Errorf("%s: unexpected error writing the version: %v", ref.Name, err)
+		}
+		if !strings.Contains(string(rewritten), "1.2.3") {
+			t.Errorf("%s: expected the version to be written", ref.Name)
+		}
+		// the versions of the replaced modules are left as is
+		if ref.Type == "gomod" && !strings.Contains(string(rewritten), "replace (\n\tgolang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d => ../oauth2\n\tgithub.com/spf13/cobra v1.0.0 =>") {
+			t.Errorf("%s: expected the replace directives to be unchanged, instead got\n%s", ref.Name, rewritten)
+		}
+	}
+
+	// a tag followed by a digest is not rewritten, the digest would still pin the old image
+	pinned := Reference{Type: "docker", Name: "alpine", Version: "3.12", File: "Dockerfile"}
+	if _, err := pinned.Location().Rewrite([]byte(testFiles["Dockerfile"]), "3.13"); err == nil {
+		t.Errorf("expected an error writing the tag of an image pinned to a digest")
+	}
+
+	if _, err := Paths(filepath.Join(dir, "chart/templates/deployment.yaml")); err == nil {
+		t.Errorf("expected an error scanning an invalid file")
+	}
+}
+
+func TestSpecs(t *testing.T) {
+	refs := []Reference{
+		{Type: "docker", Name: "nginx", Version: "1.19.2-alpine", File: "Dockerfile"},
+		{Type: "docker", Name: "busybox", Version: "1.28.1", File: "Dockerfile"},
+		{Type: "docker", Name: "nginx", Version: "1.18.0", File: "docker-compose.yml"},
+		{Type: "docker", Name: "nginx", Version: "1.18.0", File: "docker-compose.yml"},
+	}
+	specs := Specs(refs)
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 specs, instead got %d", len(specs))
+	}
+	if specs[0].Name != "nginx" || specs[0].Version != "1.19.2-alpine" || specs[0].Mask != `[0-9]+\.[0-9]+\.[0-9]+-alpine` {
+		t.Errorf("unexpected spec %+v", specs[0])
+	}
+	if len(specs[0].Locations) != 2 {
+		t.Errorf("expected the locations of both nginx references, instead got %+v", specs[0].Locations)
+	}
+
+	modules := Specs([]Reference{
+		{Type: "gomod", Name: "github.com/spf13/cobra", Version: "v1.0.0", File: "go.mod"},
+		{Type: "gomod", Name: "golang.org/x/oauth2", Version: "v0.0.0-20200107190931-bf48bf16ab8d", File: "go.mod"},
+	})
+	if modules[0].Mask != `v[0-9]+\.[0-9]+\.[0-9]+` || modules[0].UpdatePolicy != "minor" {
+		t.Errorf("unexpected spec %+v", modules[0])
+	}
+	if modules[1].Mask != "" || modules[1].UpdatePolicy != "minor" {
+		t.Errorf("unexpected spec for a pseudo-version %+v", modules[1])
+	}
+}
+
+func TestInferMask(t *testing.T) {
+	tests := map[string]string{
+		"1.19.2":            `[0-9]+\.[0-9]+\.[0-9]+`,
+		"v0.34.1":           `v[0-9]+\.[0-9]+\.[0-9]+`,
+		"3.8-alpine3.12":    `[0-9]+\.[0-9]+-alpine[0-9]+\.[0-9]+`,
+		"12.3+build":        `[0-9]+\.[0-9]+\+build`,
+		"2020.06.01-bionic": `[0-9]+\.[0-9]+\.[0-9]+-bionic`,
+	}
+	for version, expected := range tests {
+		if mask := InferMask(version); mask != expected {
+			t.Errorf("%q: expected mask %q, instead got %q", version, expected, mask)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package scan
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// scanYAML returns the images of every 'image' key in all of the documents, ie. docker-compose services and Kubernetes containers
+// Helm values with 'repository', 'tag' and an optional 'registry' under the 'image' key are also found
+func scanYAML(path string, data []byte) ([]Reference, error) {
+	refs := make([]Reference, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, yamlImages(&doc, path, nil)...)
+	}
+	return refs, nil
+}
+
+// yamlImages returns the images under the node, keys is the path to the node
+func yamlImages(node *yaml.Node, path string, keys []string) []Reference {
+	var refs []Reference
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			refs = append(refs, yamlImages(child, path, keys)...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			valueKeys := appendKey(keys, key.Value)
+			if key.Value == "image" {
+				switch value.Kind {
+				case yaml.ScalarNode:
+					if ref, ok := imageReference(value.Value, path, value.Line); ok {
+						refs = append(refs, ref)
+					}
+				case yaml.MappingNode:
+					if ref, ok := helmImage(value, path, valueKeys); ok {
+						refs = append(refs, ref)
+					}
+				}
+			}
+			refs = append(refs, yamlImages(value, path, valueKeys)...)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			refs = append(refs, yamlImages(child, path, appendKey(keys, strconv.Itoa(i)))...)
+		}
+	}
+	return refs
+}
+
+// appendKey returns a copy of the keys with the key, the keys are shared by siblings
+func appendKey(keys []string, key string) []string {
+	return append(append(make([]string, 0, len(keys)+1), keys...), key)
+}
+
+// helmImage returns the image of a mapping with 'repository', 'tag' and an optional 'registry'
+// The tag is a separate value, so its location is the YAML path of the tag
+func helmImage(node *yaml.Node, path string, keys []string) (Reference, bool) {
+	repository, tag := mappingValue(node, "repository"), mappingValue(node, "tag")
+	if repository == nil || tag == nil {
+		return Reference{}, false
+	}
+	image := repository.Value
+	if registry := mappingValue(node, "registry"); registry != nil && registry.Value != "" {
+		image = registry.Value + "/" + image
+	}
+	ref, ok := imageReference(image+":"+tag.Value, path, tag.Line)
+	ref.YAMLPath = strings.Join(appendKey(keys, "tag"), ".")
+	return ref, ok
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key && node.Content[i+1].Kind == yaml.ScalarNode {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
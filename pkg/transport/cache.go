@@ -0,0 +1,264 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CacheDirEnv overrides the default cache directory
+	CacheDirEnv = "GOFER_CACHE_DIR"
+
+	etagHeader            = "ETag"
+	lastModifiedHeader    = "Last-Modified"
+	ifNoneMatchHeader     = "If-None-Match"
+	ifModifiedSinceHeader = "If-Modified-Since"
+	cacheControlHeader    = "Cache-Control"
+
+	// responsesDir holds the responses in the cache directory, it is the only directory removed when clearing the cache
+	responsesDir = "responses"
+
+	// DefaultMaxAge is how long the default cache keeps responses that are not used
+	DefaultMaxAge = 30 * 24 * time.Hour
+)
+
+// credentialHeaders are part of the cache key, a response is never replayed for requests with other credentials
+var credentialHeaders = []string{"Authorization", "Private-Token"}
+
+// DefaultCache is used by the default transports, set Dir to "" to disable caching
+var DefaultCache = &Cache{Dir: DefaultCacheDir(), MaxAge: DefaultMaxAge}
+
+// Cache stores successful GET responses on disk
+// Responses younger than the TTL are served without a request,
+// older responses are revalidated with a conditional request using their 'ETag' or 'Last-Modified' headers
+type Cache struct {
+	// Dir is where the responses are stored, caching is disabled when empty
+	Dir string
+	// TTL is how long a response is used without revalidating it, responses are always revalidated when 0
+	TTL time.Duration
+	// MaxAge is how long a response is kept after it was last stored or revalidated, responses are kept until cleared when 0
+	MaxAge time.Duration
+
+	prune sync.Once
+}
+
+type noCacheKey struct{}
+
+// WithoutCache returns a context for requests that should never be cached, ie. requests for auth tokens
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+type credentialsKey struct{}
+
+// WithCredentials returns a context for requests with credential headers that change on every request, ie. short-lived tokens
+// The cache key uses a hash of the credentials the tokens were requested with instead of the headers
+func WithCredentials(ctx context.Context, credentials ...string) context.Context {
+	sum := sha256.Sum256([]byte(strings.Join(credentials, "\n")))
+	return context.WithValue(ctx, credentialsKey{}, hex.EncodeToString(sum[:]))
+}
+
+// DefaultCacheDir returns the 'gofer' directory in the user's cache directory, ie. '$XDG_CACHE_HOME/gofer'
+// GOFER_CACHE_DIR overrides it, an empty string is returned when there is no cache directory
+func DefaultCacheDir() string {
+	if dir := os.Getenv(CacheDirEnv); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gofer")
+}
+
+// Transport returns a http.RoundTripper that caches the responses of base
+func (c *Cache) Transport(base http.RoundTripper) http.RoundTripper {
+	return &cacheTransport{cache: c, base: base}
+}
+
+// Clear removes all of the cached responses, the rest of the cache directory is left as is
+func (c *Cache) Clear() error {
+	if c.Dir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(c.responses()); err != nil {
+		return fmt.Errorf("could not clear cache %q: %v", c.Dir, err)
+	}
+	return nil
+}
+
+func (c *Cache) responses() string {
+	return filepath.Join(c.Dir, responsesDir)
+}
+
+// Prune removes the responses that were not stored or revalidated within the MaxAge
+func (c *Cache) Prune() error {
+	if c.Dir == "" || c.MaxAge <= 0 {
+		return nil
+	}
+	files, err := ioutil.ReadDir(c.responses())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not prune cache %q: %v", c.Dir, err)
+	}
+	for _, file := range files {
+		if time.Since(file.ModTime()) > c.MaxAge {
+			os.Remove(filepath.Join(c.responses(), file.Name()))
+		}
+	}
+	return nil
+}
+
+type cacheTransport struct {
+	cache *Cache
+	base  http.RoundTripper
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.cache.Dir == "" || req.Method != "GET" || req.Context().Value(noCacheKey{}) != nil {
+		return t.base.RoundTrip(req)
+	}
+	// failing to prune the cache is not an error
+	t.cache.prune.Do(func() { t.cache.Prune() })
+
+	path := t.cache.path(req)
+	cached, stored := t.cache.load(path, req)
+	if cached != nil && t.cache.TTL > 0 && time.Since(stored) < t.cache.TTL {
+		return cached, nil
+	}
+
+	if cached != nil {
+		req = conditional(req, cached.Header)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		drain(resp.Body)
+		// restart the TTL
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return cached, nil
+	case resp.StatusCode == http.StatusOK && cacheable(resp):
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// failing to cache a response is not an error
+		t.cache.store(path, resp, body)
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// path returns the file for the request, responses depend on the URL, the requested media types and the credentials
+func (c *Cache) path(req *http.Request) string {
+	key := req.URL.String() + "\n" + req.Header.Get("Accept")
+	if credentials, ok := req.Context().Value(credentialsKey{}).(string); ok {
+		key += "\n" + credentials
+	} else {
+		for _, header := range credentialHeaders {
+			key += "\n" + req.Header.Get(header)
+		}
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.responses(), hex.EncodeToString(sum[:]))
+}
+
+// load returns the cached response and when it was stored or last revalidated, nil if it's not cached
+func (c *Cache) load(path string, req *http.Request) (*http.Response, time.Time) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, time.Time{}
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, info.ModTime()
+}
+
+func (c *Cache) store(path string, resp *http.Response, body []byte) {
+	stored := *resp
+	stored.Header = resp.Header.Clone()
+	// the rate limit of a cached response is stale
+	for header := range stored.Header {
+		if strings.Contains(strings.ToLower(header), "ratelimit") {
+			stored.Header.Del(header)
+		}
+	}
+	stored.Body = ioutil.NopCloser(bytes.NewReader(body))
+	stored.ContentLength = int64(len(body))
+	stored.TransferEncoding = nil
+	stored.Header.Del("Content-Length")
+	data, err := httputil.DumpResponse(&stored, true)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(c.responses(), 0700); err != nil {
+		return
+	}
+	// write to a temporary file first so that concurrent reads never see a partial response
+	tmp, err := ioutil.TempFile(c.responses(), ".tmp-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	os.Rename(tmp.Name(), path)
+}
+
+// conditional returns a copy of the request that is only fulfilled if the cached response changed
+func conditional(req *http.Request, cached http.Header) *http.Request {
+	etag, lastModified := cached.Get(etagHeader), cached.Get(lastModifiedHeader)
+	if etag == "" && lastModified == "" {
+		return req
+	}
+	req = req.Clone(req.Context())
+	if etag != "" && req.Header.Get(ifNoneMatchHeader) == "" {
+		req.Header.Set(ifNoneMatchHeader, etag)
+	}
+	if lastModified != "" && req.Header.Get(ifModifiedSinceHeader) == "" {
+		req.Header.Set(ifModifiedSinceHeader, lastModified)
+	}
+	return req
+}
+
+func cacheable(resp *http.Response) bool {
+	return !strings.Contains(resp.Header.Get(cacheControlHeader), "no-store")
+}
@@ -0,0 +1,225 @@
+package transport
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name                string
+		ttl                 time.Duration
+		etag                string
+		noStore             bool
+		ctx                 context.Context
+		expectedRequests    int32
+		expectedNotModified int32
+	}{
+		{
+			name:                "revalidate with etag",
+			etag:                `"v1"`,
+			ctx:                 context.Background(),
+			expectedRequests:    3,
+			expectedNotModified: 2,
+		},
+		{
+			name:             "no validators",
+			ctx:              context.Background(),
+			expectedRequests: 3,
+		},
+		{
+			name:             "within ttl",
+			ttl:              time.Hour,
+			etag:             `"v1"`,
+			ctx:              context.Background(),
+			expectedRequests: 1,
+		},
+		{
+			name:             "no-store",
+			ttl:              time.Hour,
+			noStore:          true,
+			ctx:              context.Background(),
+			expectedRequests: 3,
+		},
+		{
+			name:             "without cache",
+			ttl:              time.Hour,
+			etag:             `"v1"`,
+			ctx:              WithoutCache(context.Background()),
+			expectedRequests: 3,
+		},
+	}
+
+	for _, test := range tests {
+		dir, err := ioutil.TempDir("", "gofer-cache")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var requests, notModified int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			if test.etag != "" && r.Header.Get(ifNoneMatchHeader) == test.etag {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			if test.etag != "" {
+				w.Header().Set(etagHeader, test.etag)
+			}
+			if test.noStore {
+				w.Header().Set(cacheControlHeader, "no-store")
+			}
+			w.Write([]byte("tags"))
+		}))
+
+		cache := &Cache{Dir: dir, TTL: test.ttl}
+		client := &http.Client{Transport: cache.Transport(http.DefaultTransport)}
+		for i := 0; i < 3; i++ {
+			req, err := http.NewRequest("GET", ts.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req.WithContext(test.ctx))
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", test.name, err)
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusOK || string(body) != "tags" {
+				t.Errorf("%s: expected a 200 with %q, instead got %d with %q", test.name, "tags", resp.StatusCode, body)
+			}
+		}
+		ts.Close()
+
+		if requests != test.expectedRequests {
+			t.Errorf("%s: expected %d requests, instead got %d", test.name, test.expectedRequests, requests)
+		}
+		if notModified != test.expectedNotModified {
+			t.Errorf("%s: expected %d not modified responses, instead got %d", test.name, test.expectedNotModified, notModified)
+		}
+
+		// files that are not cached responses are never removed, the directory may be shared
+		keep := filepath.Join(dir, "keep")
+		if err := ioutil.WriteFile(keep, []byte("keep"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := cache.Clear(); err != nil {
+			t.Errorf("%s: unexpected error clearing the cache: %v", test.name, err)
+		}
+		if _, err := os.Stat(cache.responses()); !os.IsNotExist(err) {
+			t.Errorf("%s: expected the cached responses to be removed, instead got: %v", test.name, err)
+		}
+		if _, err := os.Stat(keep); err != nil {
+			t.Errorf("%s: expected other files to be kept, instead got: %v", test.name, err)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCacheCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gofer-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(r.Header.Get("Authorization") + r.Header.Get("PRIVATE-TOKEN")))
+	}))
+	defer ts.Close()
+
+	cache := &Cache{Dir: dir, TTL: time.Hour}
+	client := &http.Client{Transport: cache.Transport(http.DefaultTransport)}
+	get := func(ctx context.Context, header, value string) string {
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	// each set of credentials gets its own response, the same credentials use the cache
+	for _, request := range []struct{ header, value string }{
+		{"Authorization", "Bearer a"},
+		{"Authorization", "Bearer b"},
+		{"PRIVATE-TOKEN", "c"},
+		{"", ""},
+		{"Authorization", "Bearer a"},
+	} {
+		if body := get(context.Background(), request.header, request.value); body != request.value {
+			t.Errorf("expected the response for %q, instead got %q", request.value, body)
+		}
+	}
+	if requests != 4 {
+		t.Errorf("expected 4 requests, instead got %d", requests)
+	}
+
+	// short-lived tokens requested with the same credentials use the cache
+	ctx := WithCredentials(context.Background(), "registry.example.com", "user", "password")
+	get(ctx, "Authorization", "Bearer token-1")
+	if body := get(ctx, "Authorization", "Bearer token-2"); body != "Bearer token-1" {
+		t.Errorf("expected the cached response for %q, instead got %q", "Bearer token-1", body)
+	}
+	other := WithCredentials(context.Background(), "registry.example.com", "other", "password")
+	if body := get(other, "Authorization", "Bearer token-3"); body != "Bearer token-3" {
+		t.Errorf("expected the response for %q, instead got %q", "Bearer token-3", body)
+	}
+	if requests != 6 {
+		t.Errorf("expected 6 requests, instead got %d", requests)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gofer-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := &Cache{Dir: dir, MaxAge: time.Hour}
+	if err := os.MkdirAll(cache.responses(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	old, recent := filepath.Join(cache.responses(), "old"), filepath.Join(cache.responses(), "recent")
+	for _, path := range []string{old, recent} {
+		if err := ioutil.WriteFile(path, []byte("response"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(old, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Prune(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expected the old response to be removed, instead got: %v", err)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("expected the recent response to be kept, instead got: %v", err)
+	}
+}
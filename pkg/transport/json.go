@@ -0,0 +1,90 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// UserAgent identifies gofer to registries that require it, ie. crates.io
+const UserAgent = "gofer (https://github.com/dkoshkin/gofer)"
+
+// maxErrorBody limits how much of an error response is included in a StatusError
+const maxErrorBody = 200
+
+// StatusError is returned when a response doesn't have a 200 status
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status %q from %q", e.Status, e.URL)
+	}
+	return fmt.Sprintf("unexpected status %q from %q: %s", e.Status, e.URL, e.Body)
+}
+
+// Redact returns the URL with the password replaced by 'xxxxx', ie. for credentials set in a repository URL
+// URLs that can't be parsed are returned as is, they can't contain credentials that would be used
+func Redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+// IsNotFound returns true for a StatusError with a 404 or 410 status
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone
+}
+
+// GetJSON requests the URL and decodes the JSON response into v, a StatusError is returned when the status is not 200
+// The headers are added to the request, ie. 'Authorization'
+func GetJSON(ctx context.Context, client *http.Client, url string, header http.Header, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		text := strings.TrimSpace(string(body))
+		if len(text) > maxErrorBody {
+			text = text[:maxErrorBody] + "..."
+		}
+		return &StatusError{URL: url, StatusCode: resp.StatusCode, Status: resp.Status, Body: text}
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("could not parse the response from %q: %v", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimits records the rate limits of all requests made with the default transports
+var DefaultRateLimits = NewRateLimits()
+
+// RateLimit is the last rate limit reported by a host
+type RateLimit struct {
+	Host      string
+	Limit     int
+	Remaining int
+	// Reset is zero when the host does not report it
+	Reset time.Time
+}
+
+// RateLimits records the rate limits reported by each host
+type RateLimits struct {
+	mu     sync.Mutex
+	limits map[string]RateLimit
+}
+
+// NewRateLimits returns an empty rate limit recorder
+func NewRateLimits() *RateLimits {
+	return &RateLimits{limits: make(map[string]RateLimit)}
+}
+
+// All returns the last rate limit reported by each host, sorted by host
+func (r *RateLimits) All() []RateLimit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]RateLimit, 0, len(r.limits))
+	for _, limit := range r.limits {
+		out = append(out, limit)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+func (r *RateLimits) record(host string, header http.Header) {
+	limitHeader, remainingHeader := rateLimitLimitHeader, rateLimitRemainingHeader
+	if header.Get(remainingHeader) == "" {
+		limitHeader, remainingHeader = dockerRateLimitLimitHeader, dockerRateLimitRemainingHeader
+	}
+	remaining, ok := parseLimit(header.Get(remainingHeader))
+	if !ok {
+		return
+	}
+	limit, _ := parseLimit(header.Get(limitHeader))
+	rateLimit := RateLimit{Host: host, Limit: limit, Remaining: remaining}
+	if reset, err := strconv.ParseInt(header.Get(rateLimitResetHeader), 10, 64); err == nil {
+		rateLimit.Reset = time.Unix(reset, 0)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits[host] = rateLimit
+}
+
+// parseLimit parses the number at the start of the header, ie. '76;w=21600'
+func parseLimit(value string) (int, bool) {
+	if i := strings.Index(value, ";"); i != -1 {
+		value = value[:i]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
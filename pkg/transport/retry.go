@@ -0,0 +1,182 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 4
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+	// don't wait longer than this for a rate limit to reset, fail instead
+	defaultMaxWait = time.Minute
+
+	retryAfterHeader         = "Retry-After"
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+	// Docker Hub reports its limits without the 'X-' prefix, ie. 'ratelimit-remaining: 76;w=21600'
+	dockerRateLimitLimitHeader     = "RateLimit-Limit"
+	dockerRateLimitRemainingHeader = "RateLimit-Remaining"
+)
+
+// Retry is a http.RoundTripper that retries requests that failed with a network error, 429 or 5xx status
+// Retries use an exponential backoff with jitter and honour the 'Retry-After' and 'X-RateLimit-*' headers
+type Retry struct {
+	// Base is used to make the requests, http.DefaultTransport is used when nil
+	Base       http.RoundTripper
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxWait is the longest the transport will wait for a rate limit to reset
+	MaxWait time.Duration
+	// Limits records the rate limits reported by each host, optional
+	Limits *RateLimits
+
+	rndMu sync.Mutex
+	rnd   *rand.Rand
+}
+
+// New returns a retrying transport with the defaults that records into DefaultRateLimits
+// Responses are cached in DefaultCache
+func New() http.RoundTripper {
+	return DefaultCache.Transport(NewRetry(http.DefaultTransport))
+}
+
+// NewRetry returns a retrying transport with the defaults that wraps base
+func NewRetry(base http.RoundTripper) *Retry {
+	return &Retry{
+		Base:       base,
+		MaxRetries: defaultMaxRetries,
+		MinBackoff: defaultMinBackoff,
+		MaxBackoff: defaultMaxBackoff,
+		MaxWait:    defaultMaxWait,
+		Limits:     DefaultRateLimits,
+	}
+}
+
+func (t *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// requests with a body can only be retried if the body can be read again
+	retryable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("could not reset request body: %v", err)
+			}
+			req.Body = body
+		}
+		resp, err := base.RoundTrip(req)
+		if resp != nil && t.Limits != nil {
+			t.Limits.record(req.URL.Host, resp.Header)
+		}
+		// don't retry when the request was canceled
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+		if !retryable || attempt >= t.MaxRetries {
+			return resp, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			wait = t.backoff(attempt)
+		case shouldRetry(resp):
+			var ok bool
+			wait, ok = t.wait(resp, attempt)
+			if !ok {
+				return resp, nil
+			}
+			drain(resp.Body)
+		default:
+			return resp, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// wait returns how long to wait before the next attempt, false when it would be longer than MaxWait
+func (t *Retry) wait(resp *http.Response, attempt int) (time.Duration, bool) {
+	wait := t.backoff(attempt)
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get(retryAfterHeader)); ok {
+		wait = retryAfter
+	} else if resp.Header.Get(rateLimitRemainingHeader) == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get(rateLimitResetHeader), 10, 64)
+		if err == nil {
+			wait = time.Until(time.Unix(reset, 0))
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, wait <= t.MaxWait
+}
+
+// backoff returns an exponential backoff with full jitter
+func (t *Retry) backoff(attempt int) time.Duration {
+	max := t.MinBackoff << uint(attempt)
+	if max > t.MaxBackoff || max <= 0 {
+		max = t.MaxBackoff
+	}
+	if max <= t.MinBackoff {
+		return t.MinBackoff
+	}
+
+	t.rndMu.Lock()
+	defer t.rndMu.Unlock()
+	if t.rnd == nil {
+		t.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return t.MinBackoff + time.Duration(t.rnd.Int63n(int64(max-t.MinBackoff)))
+}
+
+func shouldRetry(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusForbidden:
+		// Github returns a 403 when the rate limit is exceeded
+		return resp.Header.Get(rateLimitRemainingHeader) == "0" || resp.Header.Get(retryAfterHeader) != ""
+	}
+	return false
+}
+
+// parseRetryAfter parses the header in either the seconds or HTTP date format
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
+
+// drain reads the rest of the body so that the connection can be reused
+func drain(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, io.LimitReader(body, 4096))
+	body.Close()
+}
@@ -0,0 +1,190 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRetry(limits *RateLimits) *Retry {
+	return &Retry{
+		MaxRetries: 3,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+		MaxWait:    time.Second,
+		Limits:     limits,
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name             string
+		responses        []func(w http.ResponseWriter)
+		expectedStatus   int
+		expectedAttempts int32
+	}{
+		{
+			name: "success",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) },
+			},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 1,
+		},
+		{
+			name: "retry server errors",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) },
+			},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 3,
+		},
+		{
+			name: "honour retry-after",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set(retryAfterHeader, "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+				},
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) },
+			},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name: "wait for the rate limit reset",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set(rateLimitRemainingHeader, "0")
+					w.Header().Set(rateLimitResetHeader, fmt.Sprintf("%d", time.Now().Unix()))
+					w.WriteHeader(http.StatusForbidden)
+				},
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) },
+			},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name: "don't wait for a rate limit reset that is too far away",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set(rateLimitRemainingHeader, "0")
+					w.Header().Set(rateLimitResetHeader, fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix()))
+					w.WriteHeader(http.StatusForbidden)
+				},
+			},
+			expectedStatus:   http.StatusForbidden,
+			expectedAttempts: 1,
+		},
+		{
+			name: "don't retry client errors",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound) },
+			},
+			expectedStatus:   http.StatusNotFound,
+			expectedAttempts: 1,
+		},
+		{
+			name: "give up after max retries",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) },
+			},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedAttempts: 4,
+		},
+	}
+
+	for _, test := range tests {
+		var attempts int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&attempts, 1)
+			test.responses[n-1](w)
+		}))
+		client := &http.Client{Transport: testRetry(nil)}
+		resp, err := client.Get(ts.URL)
+		ts.Close()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.expectedStatus {
+			t.Errorf("%s: expected status %d, instead got %d", test.name, test.expectedStatus, resp.StatusCode)
+		}
+		if attempts != test.expectedAttempts {
+			t.Errorf("%s: expected %d attempts, instead got %d", test.name, test.expectedAttempts, attempts)
+		}
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(retryAfterHeader, "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequest("GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: testRetry(nil)}
+	_, err = client.Do(req.WithContext(ctx))
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("expected a deadline exceeded error, instead got: %v", err)
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/github", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(rateLimitLimitHeader, "60")
+		w.Header().Set(rateLimitRemainingHeader, "42")
+		w.Header().Set(rateLimitResetHeader, fmt.Sprintf("%d", reset.Unix()))
+	})
+	mux.HandleFunc("/dockerhub", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(dockerRateLimitLimitHeader, "100;w=21600")
+		w.Header().Set(dockerRateLimitRemainingHeader, "76;w=21600")
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	limits := NewRateLimits()
+	client := &http.Client{Transport: testRetry(limits)}
+	for _, path := range []string{"/github", "/dockerhub"} {
+		resp, err := client.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		all := limits.All()
+		if len(all) != 1 {
+			t.Fatalf("expected 1 rate limit, instead got %d", len(all))
+		}
+		switch path {
+		case "/github":
+			if all[0].Limit != 60 || all[0].Remaining != 42 || !all[0].Reset.Equal(reset) {
+				t.Errorf("unexpected rate limit %+v", all[0])
+			}
+		case "/dockerhub":
+			if all[0].Limit != 100 || all[0].Remaining != 76 || !all[0].Reset.IsZero() {
+				t.Errorf("unexpected rate limit %+v", all[0])
+			}
+		}
+	}
+}
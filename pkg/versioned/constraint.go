@@ -0,0 +1,223 @@
+package versioned
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Constraint is a semver range in the npm style, ie. '^1.17', '~1.17.3', '>=1.16 <2' or '!=1.17.4'
+// Comparators separated by spaces or commas must all match, '||' separates alternative ranges
+// A version without an operator is an exact match, a partial version such as '1.17' or '1.17.x' matches any patch
+type Constraint struct {
+	raw string
+	// a version satisfies the constraint when it matches all of the comparators in any of the sets
+	sets [][]comparator
+}
+
+type comparator struct {
+	op      string
+	version Semver
+	// set for '!=' with a partial version, the excluded range is [version, upper)
+	upper *Semver
+}
+
+var (
+	operatorRgx     = regexp.MustCompile(`^(\^|~|>=|<=|>|<|==|=|!=)?\s*(.*)$`)
+	operatorOnlyRgx = regexp.MustCompile(`^(\^|~|>=|<=|>|<|==|=|!=)$`)
+	wildcards       = map[string]bool{"": true, "*": true, "x": true, "X": true}
+)
+
+// lowestPrerelease sorts before any other pre-release of the same version
+const lowestPrerelease = "0"
+
+// ParseConstraint parses a semver range
+func ParseConstraint(in string) (*Constraint, error) {
+	c := &Constraint{raw: in}
+	for _, rng := range strings.Split(in, "||") {
+		set, err := parseRange(rng)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %v", in, err)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+func (c Constraint) String() string {
+	return c.raw
+}
+
+// Check returns true if the version satisfies the constraint
+// Versions that are not semantic versions never satisfy a constraint
+func (c Constraint) Check(v Versioned) bool {
+	sv, err := v.Semver()
+	if err != nil {
+		return false
+	}
+	for _, set := range c.sets {
+		matched := true
+		for _, comp := range set {
+			if !comp.check(*sv) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (comp comparator) check(sv Semver) bool {
+	c := sv.Compare(comp.version)
+	switch comp.op {
+	case "=":
+		return c == 0
+	case "!=":
+		if comp.upper != nil {
+			return c < 0 || sv.Compare(*comp.upper) >= 0
+		}
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
+
+func parseRange(rng string) ([]comparator, error) {
+	tokens := strings.Fields(strings.Replace(rng, ",", " ", -1))
+	// join operators separated from their versions, ie. '>= 1.16'
+	joined := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if operatorOnlyRgx.MatchString(tokens[i]) && i+1 < len(tokens) {
+			joined = append(joined, tokens[i]+tokens[i+1])
+			i++
+			continue
+		}
+		joined = append(joined, tokens[i])
+	}
+
+	set := make([]comparator, 0)
+	for i := 0; i < len(joined); i++ {
+		// hyphen range, ie. '1.2 - 1.4'
+		if i+2 < len(joined) && joined[i+1] == "-" {
+			comparators, err := hyphenRange(joined[i], joined[i+2])
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparators...)
+			i += 2
+			continue
+		}
+		comparators, err := expand(joined[i])
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, comparators...)
+	}
+	if len(set) == 0 && strings.TrimSpace(rng) != "" && !wildcards[strings.TrimSpace(rng)] {
+		return nil, fmt.Errorf("could not parse %q", rng)
+	}
+	return set, nil
+}
+
+func expand(token string) ([]comparator, error) {
+	matches := operatorRgx.FindStringSubmatch(token)
+	op, v := matches[1], matches[2]
+	if wildcards[v] {
+		// '*' matches everything, '!=*' would match nothing
+		if op == "!=" || op == "<" || op == ">" {
+			return nil, fmt.Errorf("could not parse %q", token)
+		}
+		return nil, nil
+	}
+	sv, err := ParseSemver(v)
+	if err != nil {
+		return nil, err
+	}
+	if sv.parts == 0 {
+		return nil, fmt.Errorf("could not parse %q", token)
+	}
+	partial := sv.parts < 3
+
+	switch op {
+	case "", "=", "==":
+		if !partial {
+			return []comparator{{op: "=", version: *sv}}, nil
+		}
+		return []comparator{{op: ">=", version: *sv}, {op: "<", version: nextVersion(*sv)}}, nil
+	case "!=":
+		if !partial {
+			return []comparator{{op: "!=", version: *sv}}, nil
+		}
+		upper := nextVersion(*sv)
+		return []comparator{{op: "!=", version: *sv, upper: &upper}}, nil
+	case "^":
+		return []comparator{{op: ">=", version: *sv}, {op: "<", version: caretUpper(*sv)}}, nil
+	case "~":
+		upper := Semver{Major: sv.Major, Minor: sv.Minor + 1, Prerelease: lowestPrerelease}
+		if sv.parts == 1 {
+			upper = Semver{Major: sv.Major + 1, Prerelease: lowestPrerelease}
+		}
+		return []comparator{{op: ">=", version: *sv}, {op: "<", version: upper}}, nil
+	case ">":
+		if partial {
+			return []comparator{{op: ">=", version: nextVersion(*sv)}}, nil
+		}
+		return []comparator{{op: ">", version: *sv}}, nil
+	case "<=":
+		if partial {
+			return []comparator{{op: "<", version: nextVersion(*sv)}}, nil
+		}
+		return []comparator{{op: "<=", version: *sv}}, nil
+	default:
+		return []comparator{{op: op, version: *sv}}, nil
+	}
+}
+
+func hyphenRange(from, to string) ([]comparator, error) {
+	lower, err := ParseSemver(from)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := ParseSemver(to)
+	if err != nil {
+		return nil, err
+	}
+	if upper.parts < 3 {
+		return []comparator{{op: ">=", version: *lower}, {op: "<", version: nextVersion(*upper)}}, nil
+	}
+	return []comparator{{op: ">=", version: *lower}, {op: "<=", version: *upper}}, nil
+}
+
+// nextVersion returns the first version after all of the versions matched by a partial version
+// ie. '1.17' returns '1.18.0-0' and '1' returns '2.0.0-0'
+// the lowest pre-release is used so that pre-releases of the next version are not matched
+func nextVersion(sv Semver) Semver {
+	switch sv.parts {
+	case 1:
+		return Semver{Major: sv.Major + 1, Prerelease: lowestPrerelease}
+	case 2:
+		return Semver{Major: sv.Major, Minor: sv.Minor + 1, Prerelease: lowestPrerelease}
+	}
+	return Semver{Major: sv.Major, Minor: sv.Minor, Patch: sv.Patch + 1, Prerelease: lowestPrerelease}
+}
+
+// caretUpper allows changes that do not modify the left-most non-zero number
+func caretUpper(sv Semver) Semver {
+	switch {
+	case sv.Major > 0 || sv.parts == 1:
+		return Semver{Major: sv.Major + 1, Prerelease: lowestPrerelease}
+	case sv.Minor > 0 || sv.parts == 2:
+		return Semver{Minor: sv.Minor + 1, Prerelease: lowestPrerelease}
+	}
+	return Semver{Patch: sv.Patch + 1, Prerelease: lowestPrerelease}
+}
@@ -0,0 +1,160 @@
+package versioned
+
+import (
+	"testing"
+)
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []Versioned
+		misses     []Versioned
+	}{
+		{
+			constraint: "^1.17",
+			matches:    []Versioned{"v1.17.0", "v1.17.5", "1.18.2", "v1.99.0"},
+			misses:     []Versioned{"v1.16.9", "v2.0.0", "v2.0.0-rc.1", "latest"},
+		},
+		{
+			constraint: "^0.2.3",
+			matches:    []Versioned{"0.2.3", "0.2.9"},
+			misses:     []Versioned{"0.2.2", "0.3.0", "1.0.0"},
+		},
+		{
+			constraint: "~1.17.3",
+			matches:    []Versioned{"v1.17.3", "v1.17.10"},
+			misses:     []Versioned{"v1.17.2", "v1.18.0"},
+		},
+		{
+			constraint: ">=1.16 <2",
+			matches:    []Versioned{"v1.16.0", "v1.19.3"},
+			misses:     []Versioned{"v1.15.9", "v2.0.0"},
+		},
+		{
+			constraint: ">= 1.16, < 2",
+			matches:    []Versioned{"v1.16.0", "v1.19.3"},
+			misses:     []Versioned{"v1.15.9", "v2.0.0"},
+		},
+		{
+			constraint: "!=1.17.4",
+			matches:    []Versioned{"v1.17.3", "v1.17.5"},
+			misses:     []Versioned{"v1.17.4"},
+		},
+		{
+			constraint: "^1.17 !=1.17.4",
+			matches:    []Versioned{"v1.17.3", "v1.17.5"},
+			misses:     []Versioned{"v1.17.4", "v2.0.0"},
+		},
+		{
+			constraint: "1.17.x",
+			matches:    []Versioned{"v1.17.0", "v1.17.9"},
+			misses:     []Versioned{"v1.18.0"},
+		},
+		{
+			constraint: "1.2.3",
+			matches:    []Versioned{"1.2.3", "v1.2.3"},
+			misses:     []Versioned{"1.2.4"},
+		},
+		{
+			constraint: "1.2 - 1.4",
+			matches:    []Versioned{"1.2.0", "1.4.9"},
+			misses:     []Versioned{"1.1.9", "1.5.0"},
+		},
+		{
+			constraint: "^1.2 || ^3",
+			matches:    []Versioned{"1.2.0", "3.1.0"},
+			misses:     []Versioned{"2.0.0", "4.0.0"},
+		},
+		{
+			constraint: ">1.2",
+			matches:    []Versioned{"1.3.0"},
+			misses:     []Versioned{"1.2.9"},
+		},
+		{
+			constraint: "*",
+			matches:    []Versioned{"1.3.0", "0.0.1"},
+			misses:     []Versioned{"edge"},
+		},
+	}
+
+	for _, test := range tests {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", test.constraint, err)
+			continue
+		}
+		for _, v := range test.matches {
+			if !c.Check(v) {
+				t.Errorf("expected %q to match %q", v, test.constraint)
+			}
+		}
+		for _, v := range test.misses {
+			if c.Check(v) {
+				t.Errorf("expected %q not to match %q", v, test.constraint)
+			}
+		}
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, constraint := range []string{"^foo", ">=1.2 <bar", "!=*", "1.2.3.4"} {
+		if _, err := ParseConstraint(constraint); err == nil {
+			t.Errorf("expected an error parsing %q", constraint)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	ordered := []string{"1.0.0-0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	for i := 0; i < len(ordered)-1; i++ {
+		a, err := ParseSemver(ordered[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseSemver(ordered[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Compare(*b) != -1 || b.Compare(*a) != 1 {
+			t.Errorf("expected %q to be less than %q", ordered[i], ordered[i+1])
+		}
+	}
+}
+
+func TestUpdateKind(t *testing.T) {
+	tests := []struct {
+		from     Versioned
+		to       Versioned
+		expected string
+	}{
+		{from: "1.17.5", to: "2.0.0", expected: UpdateMajor},
+		{from: "v1.17.5", to: "v1.18.0", expected: UpdateMinor},
+		{from: "v1.17.5", to: "v1.17.6", expected: UpdatePatch},
+		{from: "1.17", to: "1.17.1", expected: UpdatePatch},
+		{from: "1.18.0-rc.1", to: "1.18.0", expected: UpdatePatch},
+		{from: "1.17.5", to: "v1.17.5", expected: ""},
+		{from: "latest", to: "1.17.5", expected: ""},
+	}
+	for _, test := range tests {
+		if kind := UpdateKind(test.from, test.to); kind != test.expected {
+			t.Errorf("expected update from %q to %q to be %q, instead got %q", test.from, test.to, test.expected, kind)
+		}
+	}
+
+	atLeast := []struct {
+		kind     string
+		min      string
+		expected bool
+	}{
+		{kind: UpdateMajor, min: UpdatePatch, expected: true},
+		{kind: UpdateMinor, min: UpdateMinor, expected: true},
+		{kind: UpdatePatch, min: UpdateMinor, expected: false},
+		{kind: UpdateMinor, min: UpdateMajor, expected: false},
+		{kind: "", min: UpdateMajor, expected: true},
+	}
+	for _, test := range atLeast {
+		if got := UpdateKindAtLeast(test.kind, test.min); got != test.expected {
+			t.Errorf("expected %q to be at least %q to be %t, instead got %t", test.kind, test.min, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package versioned
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Semver is a parsed semantic version
+// Missing minor and patch numbers are treated as 0, ie. 'v1.17' is '1.17.0'
+type Semver struct {
+	Major      int64
+	Minor      int64
+	Patch      int64
+	Prerelease string
+	Build      string
+
+	// number of numeric parts that were set, used for partial versions in constraints
+	parts int
+}
+
+var semverRgx = regexp.MustCompile(`^[vV]?([0-9]+)(?:\.([0-9]+|[xX*]))?(?:\.([0-9]+|[xX*]))?(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+
+// ParseSemver parses a version that is in the semver format, the 'v' prefix is optional
+func ParseSemver(in string) (*Semver, error) {
+	matches := semverRgx.FindStringSubmatch(strings.TrimSpace(in))
+	if matches == nil {
+		return nil, fmt.Errorf("%q is not a semantic version", in)
+	}
+	sv := &Semver{Prerelease: matches[4], Build: matches[5]}
+	numbers := []*int64{&sv.Major, &sv.Minor, &sv.Patch}
+	for i, part := range matches[1:4] {
+		if part == "" || part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a semantic version: %v", in, err)
+		}
+		*numbers[i] = n
+		sv.parts++
+	}
+	return sv, nil
+}
+
+// Semver parses the version as a semantic version
+func (v Versioned) Semver() (*Semver, error) {
+	return ParseSemver(string(v))
+}
+
+func (s Semver) String() string {
+	out := fmt.Sprintf("%d.%d.%d", s.Major, s.Minor, s.Patch)
+	if s.Prerelease != "" {
+		out += "-" + s.Prerelease
+	}
+	if s.Build != "" {
+		out += "+" + s.Build
+	}
+	return out
+}
+
+// Compare returns -1, 0 or 1 if the version is less than, equal to or greater than other
+// Build metadata is ignored as required by the semver spec
+func (s Semver) Compare(other Semver) int {
+	if c := compareInt(s.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(s.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(s.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(s.Prerelease, other.Prerelease)
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// a version without a pre-release has a higher precedence
+// identifiers are compared numerically when both are numbers and lexically otherwise
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.ParseInt(aParts[i], 10, 64)
+		bNum, bErr := strconv.ParseInt(bParts[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(aNum, bNum); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(int64(len(aParts)), int64(len(bParts)))
+}
+
+// Kinds of updates between two versions
+const (
+	UpdateMajor = "major"
+	UpdateMinor = "minor"
+	UpdatePatch = "patch"
+)
+
+// ValidUpdateKinds lists the kinds of updates from the largest to the smallest
+var ValidUpdateKinds = []string{UpdateMajor, UpdateMinor, UpdatePatch}
+
+// UpdateKind returns the left-most part of the version that is different, ie. 'minor' for '1.17.5' to '1.18.0'
+// A change to only the pre-release is a 'patch', an empty string is returned when either version is not semantic or they are equal
+func UpdateKind(from, to Versioned) string {
+	fromSemver, err := from.Semver()
+	if err != nil {
+		return ""
+	}
+	toSemver, err := to.Semver()
+	if err != nil {
+		return ""
+	}
+	switch {
+	case fromSemver.Major != toSemver.Major:
+		return UpdateMajor
+	case fromSemver.Minor != toSemver.Minor:
+		return UpdateMinor
+	case fromSemver.Compare(*toSemver) != 0:
+		return UpdatePatch
+	}
+	return ""
+}
+
+// UpdateKindAtLeast returns true when the kind of update is at least as large as min, ie. 'major' is at least 'minor'
+// Unknown kinds could be any update and are always at least min
+func UpdateKindAtLeast(kind, min string) bool {
+	return updateKindRank(kind) <= updateKindRank(min)
+}
+
+func updateKindRank(kind string) int {
+	for i, k := range ValidUpdateKinds {
+		if k == kind {
+			return i
+		}
+	}
+	return -1
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/mcuadros/go-version"
 )
@@ -19,8 +21,55 @@ func (v Versioned) String() string {
 	return string(v)
 }
 
+// IsPrerelease returns true when the version string has a pre-release segment, ie. 'v1.18.0-rc.1'
+// variants such as '3.8-alpine' are not pre-releases
+func (v Versioned) IsPrerelease() bool {
+	suffix := leadingVersionRgx.ReplaceAllString(string(v), "")
+	// nothing to check without a numeric version or a suffix
+	if suffix == string(v) || suffix == "" {
+		return false
+	}
+	// ignore build metadata
+	if i := strings.Index(suffix, "+"); i != -1 {
+		suffix = suffix[:i]
+	}
+	suffix = strings.ToLower(suffix)
+	// single letters are only a pre-release when they are the entire suffix, ie. '1.0.0b1' but not '1.0.0-a1b2c3d'
+	if shortPrereleaseRgx.MatchString(suffix) {
+		return true
+	}
+	for _, identifier := range identifierSplitRgx.FindAllString(suffix, -1) {
+		if prereleaseIdentifiers[identifier] {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	leadingVersionRgx  = regexp.MustCompile(`^[vV]?[0-9]+(\.[0-9]+)*`)
+	identifierSplitRgx = regexp.MustCompile(`[a-z]+`)
+	shortPrereleaseRgx = regexp.MustCompile(`^[.-]?[abm][.-]?[0-9]*$`)
+
+	prereleaseIdentifiers = map[string]bool{
+		"alpha": true, "beta": true, "rc": true, "pre": true, "preview": true,
+		"dev": true, "snapshot": true, "nightly": true, "canary": true, "milestone": true,
+	}
+)
+
+// Metadata is additional information about a version provided by its source
+type Metadata struct {
+	Prerelease bool
+	// Published is when the version was published, zero when the source did not provide it
+	Published time.Time
+	// Note is information about the version to show next to it, ie. the app version of a Helm chart
+	Note string
+}
+
 type Versions struct {
 	List []Versioned
+	// Metadata is optional and keyed by version
+	Metadata map[Versioned]Metadata
 }
 
 func FromStringSlice(in []string) *Versions {
@@ -39,7 +88,19 @@ func (t Versions) Len() int {
 }
 
 func (t *Versions) Less(i, j int) bool {
-	return version.CompareSimple(version.Normalize(string(t.List[i])), version.Normalize(string(t.List[j]))) == -1
+	// build metadata does not affect the precedence, ie. 'v2.0.0+incompatible', compare the full versions only when the rest is the same
+	a, b := withoutBuildMetadata(t.List[i]), withoutBuildMetadata(t.List[j])
+	if cmp := version.CompareSimple(version.Normalize(a), version.Normalize(b)); cmp != 0 || a != b {
+		return cmp == -1
+	}
+	return t.List[i] < t.List[j]
+}
+
+func withoutBuildMetadata(v Versioned) string {
+	if i := strings.Index(string(v), "+"); i != -1 {
+		return string(v)[:i]
+	}
+	return string(v)
 }
 
 func (t *Versions) Swap(i, j int) {
@@ -53,6 +114,19 @@ func (t Versions) Last() *Versioned {
 	return &t.List[len(t.List)-1]
 }
 
+// IsPrerelease returns true if the source marked the version as a pre-release or the version string has a pre-release segment
+func (t Versions) IsPrerelease(v Versioned) bool {
+	return t.Metadata[v].Prerelease || v.IsPrerelease()
+}
+
+// SetMetadata stores metadata for a version
+func (t *Versions) SetMetadata(v Versioned, metadata Metadata) {
+	if t.Metadata == nil {
+		t.Metadata = make(map[Versioned]Metadata)
+	}
+	t.Metadata[v] = metadata
+}
+
 func (t Versions) Latest() *Versioned {
 	sort.Sort(&t)
 	return t.Last()
@@ -66,7 +140,7 @@ func Filter(in *Versions, mask string) *Versions {
 	}
 	// with mask filter out
 	rgxMask := regexp.MustCompile(fmt.Sprintf("^%s$", mask))
-	filteredVersions := &Versions{}
+	filteredVersions := &Versions{Metadata: in.Metadata}
 	for _, tag := range in.List {
 		if rgxMask.MatchString(string(tag)) {
 			filteredVersions.List = append(filteredVersions.List, tag)
@@ -74,3 +148,14 @@ func Filter(in *Versions, mask string) *Versions {
 	}
 	return filteredVersions
 }
+
+// FilterFunc returns the versions for which the keep function returns true
+func FilterFunc(in *Versions, keep func(v Versioned) bool) *Versions {
+	filteredVersions := &Versions{Metadata: in.Metadata}
+	for _, v := range in.List {
+		if keep(v) {
+			filteredVersions.List = append(filteredVersions.List, v)
+		}
+	}
+	return filteredVersions
+}
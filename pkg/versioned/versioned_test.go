@@ -15,6 +15,7 @@ func TestLatest(t *testing.T) {
 		{versions: FromStringSlice([]string{"v1.10.6", "v1.10.5", "v1.10.4", "alpha", "stable", "v1.9.10"}), expected: FromString("v1.10.6")},
 		{versions: FromStringSlice([]string{"2.6", "2.7", "3.1", "3.2", "3.3", "3.4", "3.5", "3.6", "3.7", "3.8", "edge", "latest"}), expected: FromString("3.8")},
 		{versions: FromStringSlice([]string{"v1.10.0", "v1.10.0-alpha.0", "v1.10.0-alpha.1", "v1.10.0-alpha.2", "v1.10.0-alpha.3", "v1.10.0-beta.0", "v1.10.0-beta.1", "v1.10.0-beta.2", "v1.10.0-beta.3", "v1.10.0-beta.4", "v1.10.0-rc.1"}), expected: FromString("v1.10.0")},
+		{versions: FromStringSlice([]string{"v1.5.0", "v10.0.0+incompatible", "v2.1.0+incompatible", "v1.10.0"}), expected: FromString("v10.0.0+incompatible")},
 		{versions: FromStringSlice([]string{"v1.10.0", "v1.11.0-alpha.0", "v1.11.0-alpha.1", "v1.11.0-alpha.2", "v1.11.0-alpha.3", "v1.11.0-beta.0", "v1.11.0-beta.1", "v1.11.0-beta.2", "v1.11.0-beta.3", "v1.11.0-beta.4", "v1.11.0-rc.1"}), expected: FromString("v1.11.0-rc.1")},
 	}
 
@@ -56,7 +57,33 @@ func TestFilter(t *testing.T) {
 	for _, test := range tests {
 		filtered := Filter(test.versions, test.mask)
 		if len(test.expected.List) > 0 && !reflect.DeepEqual(filtered, test.expected) {
-			t.Errorf("expected filtered tags to be %q, instead got %q", test.expected, filtered)
+			t.Errorf("expected filtered tags to be %q, instead got %q", test.expected.List, filtered.List)
+		}
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := []struct {
+		version  Versioned
+		expected bool
+	}{
+		{version: "v1.18.0", expected: false},
+		{version: "v1.18.0-rc.1", expected: true},
+		{version: "v1.11.0-alpha.0", expected: true},
+		{version: "1.0.0-beta", expected: true},
+		{version: "2.0.0b1", expected: true},
+		{version: "5.0.0-M1", expected: true},
+		{version: "3.8-alpine", expected: false},
+		{version: "1.21.3-alpine3.12", expected: false},
+		{version: "v1.2.3-a1b2c3d", expected: false},
+		{version: "v2.0.0+incompatible", expected: false},
+		{version: "latest", expected: false},
+		{version: "edge", expected: false},
+	}
+
+	for _, test := range tests {
+		if prerelease := test.version.IsPrerelease(); prerelease != test.expected {
+			t.Errorf("expected %q pre-release to be %t, instead got %t", test.version, test.expected, prerelease)
 		}
 	}
 }